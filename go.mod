@@ -9,7 +9,7 @@ require (
 	github.com/gin-gonic/gin v1.10.0
 	github.com/grahms/godantic v1.5.0
 	github.com/stretchr/testify v1.10.0
-
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -41,5 +41,4 @@ require (
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,154 @@
+package worx
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	HealthUp   = "UP"
+	HealthDown = "DOWN"
+)
+
+// HealthReport is the body of the health endpoints.
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the outcome of the last run of a health check.
+type HealthCheckResult struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthCheck reports whether a dependency of the application is usable,
+// ex: by pinging a database.
+type HealthCheck func(ctx context.Context) error
+
+// HealthCheckOption configures a health check.
+type HealthCheckOption func(*healthCheck)
+
+// WithCheckTimeout fails the check when it runs for longer than timeout, 5
+// seconds by default.
+func WithCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(h *healthCheck) {
+		h.timeout = timeout
+	}
+}
+
+// WithCheckCache reuses the result of the check for ttl before running it
+// again, 10 seconds by default. Zero runs the check on every probe.
+func WithCheckCache(ttl time.Duration) HealthCheckOption {
+	return func(h *healthCheck) {
+		h.ttl = ttl
+	}
+}
+
+type healthCheck struct {
+	name    string
+	check   HealthCheck
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu     sync.Mutex
+	result HealthCheckResult
+}
+
+// run returns the cached result while it is fresh, otherwise runs the check.
+// Concurrent probes wait for a single run. The check is detached from the
+// cancellation of ctx, so a probe client giving up does not cache a failure,
+// and is bounded by the check timeout instead.
+func (h *healthCheck) run(ctx context.Context) HealthCheckResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.result.CheckedAt.IsZero() && time.Since(h.result.CheckedAt) < h.ttl {
+		return h.result
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- h.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	h.result = HealthCheckResult{Status: HealthUp, Duration: time.Since(start).String(), CheckedAt: start}
+	if err != nil {
+		h.result.Status, h.result.Error = HealthDown, err.Error()
+	}
+	return h.result
+}
+
+// AddHealthCheck makes the application ready only while check passes.
+func (a *Application) AddHealthCheck(name string, check HealthCheck, opts ...HealthCheckOption) {
+	h := &healthCheck{name: name, check: check, timeout: 5 * time.Second, ttl: 10 * time.Second}
+	for _, opt := range opts {
+		opt(h)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.healthChecks = append(a.healthChecks, h)
+}
+
+// Ready runs the health checks and reports whether the application can serve
+// requests. Applications shutting down are never ready.
+func (a *Application) Ready(ctx context.Context) HealthReport {
+	a.mu.Lock()
+	checks := a.healthChecks
+	a.mu.Unlock()
+
+	report := HealthReport{Status: HealthUp, Checks: make(map[string]HealthCheckResult, len(checks))}
+	results := make([]HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, h := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx)
+		}()
+	}
+	wg.Wait()
+	for i, h := range checks {
+		report.Checks[h.name] = results[i]
+		if results[i].Status != HealthUp {
+			report.Status = HealthDown
+		}
+	}
+	if a.shuttingDown() {
+		report.Status = HealthDown
+	}
+	return report
+}
+
+// renderHealth mounts the liveness and readiness probes. Liveness only tells
+// the process serves requests, readiness runs the health checks.
+func (a *Application) renderHealth() {
+	a.Engine.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, HealthReport{Status: HealthUp})
+	})
+	a.Engine.GET("/health/ready", func(c *gin.Context) {
+		report := a.Ready(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != HealthUp {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
@@ -0,0 +1,112 @@
+package worx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func probe(app *Application, path string) (int, HealthReport) {
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var report HealthReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report
+}
+
+func TestHealthChecks(t *testing.T) {
+	t.Run("should be live and ready without checks", func(t *testing.T) {
+		app := newTestApplication()
+		code, report := probe(app, "/health/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, HealthUp, report.Status)
+
+		code, report = probe(app, "/health/ready")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, HealthUp, report.Status)
+	})
+
+	t.Run("should report each check", func(t *testing.T) {
+		app := newTestApplication()
+		app.AddHealthCheck("database", func(context.Context) error { return nil })
+		app.AddHealthCheck("cache", func(context.Context) error { return errors.New("connection refused") })
+
+		code, report := probe(app, "/health/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, HealthDown, report.Status)
+		assert.Equal(t, HealthUp, report.Checks["database"].Status)
+		assert.Equal(t, HealthDown, report.Checks["cache"].Status)
+		assert.Equal(t, "connection refused", report.Checks["cache"].Error)
+
+		code, _ = probe(app, "/health/live")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("should time out slow checks", func(t *testing.T) {
+		app := newTestApplication()
+		app.AddHealthCheck("slow", func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}, WithCheckTimeout(20*time.Millisecond))
+
+		start := time.Now()
+		code, report := probe(app, "/health/ready")
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	})
+
+	t.Run("should cache results", func(t *testing.T) {
+		app := newTestApplication()
+		var runs atomic.Int32
+		app.AddHealthCheck("cached", func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}, WithCheckCache(time.Minute))
+		app.AddHealthCheck("uncached", func(context.Context) error {
+			runs.Add(10)
+			return nil
+		}, WithCheckCache(0))
+
+		probe(app, "/health/ready")
+		probe(app, "/health/ready")
+		assert.Equal(t, int32(21), runs.Load())
+	})
+
+	t.Run("should not cache failures of canceled probes", func(t *testing.T) {
+		app := newTestApplication()
+		app.AddHealthCheck("database", func(ctx context.Context) error {
+			return ctx.Err()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil).WithContext(ctx))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		code, _ := probe(app, "/health/ready")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("should not be ready while shutting down", func(t *testing.T) {
+		app := newTestApplication()
+		app.SetServerTimeouts(ServerTimeouts{Drain: 200 * time.Millisecond})
+		url, done := startTestApplication(t, app, context.Background())
+		time.Sleep(50 * time.Millisecond)
+
+		go app.Shutdown(context.Background())
+		time.Sleep(50 * time.Millisecond)
+		resp, err := http.Get(url + "/health/ready")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		resp.Body.Close()
+		assert.Nil(t, <-done)
+	})
+}
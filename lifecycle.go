@@ -0,0 +1,224 @@
+package worx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ServerTimeouts bounds the time the HTTP server spends on a connection.
+type ServerTimeouts struct {
+	// Read bounds reading a whole request, body included.
+	Read time.Duration
+	// Write bounds writing the response, from the end of the request headers.
+	Write time.Duration
+	// Idle bounds waiting for the next request on keep-alive connections.
+	Idle time.Duration
+	// Shutdown bounds draining in-flight requests and running the stop hooks
+	// when RunContext stops because its context ended.
+	Shutdown time.Duration
+	// Drain keeps accepting requests for this long once shutdown started and
+	// readiness fails, giving load balancers time to stop routing to the
+	// application.
+	Drain time.Duration
+}
+
+// DefaultServerTimeouts are the timeouts applications start with.
+var DefaultServerTimeouts = ServerTimeouts{
+	Read:     30 * time.Second,
+	Write:    60 * time.Second,
+	Idle:     2 * time.Minute,
+	Shutdown: 30 * time.Second,
+}
+
+// Hook is run when the application starts or stops.
+type Hook func(ctx context.Context) error
+
+// lifecycle tracks the server and the hooks of a running application.
+type lifecycle struct {
+	mu       sync.Mutex
+	docs     sync.Once
+	server   *http.Server
+	onStart  []Hook
+	onStop   []Hook
+	stopping bool
+	stopped  chan struct{}
+	stopErr  error
+}
+
+// SetServerTimeouts replaces the timeouts of the HTTP server, zero values
+// disabling the matching timeout.
+func (a *Application) SetServerTimeouts(timeouts ServerTimeouts) {
+	a.timeouts = timeouts
+}
+
+// OnStart runs hook before the application accepts requests. Hooks run in
+// registration order and the first failing one aborts the start.
+func (a *Application) OnStart(hook Hook) {
+	a.lifecycle.mu.Lock()
+	defer a.lifecycle.mu.Unlock()
+	a.lifecycle.onStart = append(a.lifecycle.onStart, hook)
+}
+
+// OnStop runs hook once the application stopped accepting requests and the
+// in-flight ones completed. Hooks run in reverse registration order, also when
+// a start hook failed, so they must tolerate what they stop not being started.
+func (a *Application) OnStop(hook Hook) {
+	a.lifecycle.mu.Lock()
+	defer a.lifecycle.mu.Unlock()
+	a.lifecycle.onStop = append(a.lifecycle.onStop, hook)
+}
+
+// Run serves the application on address until it receives SIGINT or SIGTERM,
+// then shuts it down gracefully.
+func (a *Application) Run(address string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.RunContext(ctx, address)
+}
+
+// RunContext runs the start hooks and serves the application on address until
+// ctx ends or Shutdown is called. When ctx ends the application is shut down
+// within the Shutdown timeout.
+func (a *Application) RunContext(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return a.serve(ctx, listener)
+}
+
+func (a *Application) serve(ctx context.Context, listener net.Listener) error {
+	a.lifecycle.docs.Do(a.renderDocs)
+	server := &http.Server{
+		Handler:      a.Engine.Handler(),
+		ReadTimeout:  a.timeouts.Read,
+		WriteTimeout: a.timeouts.Write,
+		IdleTimeout:  a.timeouts.Idle,
+		BaseContext:  func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	l := &a.lifecycle
+	l.mu.Lock()
+	if l.server != nil {
+		l.mu.Unlock()
+		_ = listener.Close()
+		return errors.New("worx: application is already running")
+	}
+	l.server, l.stopping, l.stopped, l.stopErr = server, false, make(chan struct{}), nil
+	l.mu.Unlock()
+
+	if err := a.start(ctx); err != nil {
+		_ = listener.Close()
+		shutdownCtx, cancel := a.shutdownContext(ctx)
+		defer cancel()
+		return errors.Join(err, a.Shutdown(shutdownCtx))
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		if !errors.Is(err, http.ErrServerClosed) {
+			shutdownCtx, cancel := a.shutdownContext(ctx)
+			defer cancel()
+			return errors.Join(err, a.Shutdown(shutdownCtx))
+		}
+		// Shutdown was called, wait for it to drain and stop the hooks.
+		<-l.stopped
+		return l.stopErr
+	case <-ctx.Done():
+		shutdownCtx, cancel := a.shutdownContext(ctx)
+		defer cancel()
+		return a.Shutdown(shutdownCtx)
+	}
+}
+
+// Shutdown stops accepting requests, waits for the in-flight ones to complete
+// and runs the stop hooks. Requests still running when ctx ends are left to
+// their connections being closed.
+func (a *Application) Shutdown(ctx context.Context) error {
+	l := &a.lifecycle
+	l.mu.Lock()
+	server, stopped := l.server, l.stopped
+	if server == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	if l.stopping {
+		l.mu.Unlock()
+		<-stopped
+		return l.stopErr
+	}
+	l.stopping = true
+	l.mu.Unlock()
+
+	if a.timeouts.Drain > 0 {
+		select {
+		case <-time.After(a.timeouts.Drain):
+		case <-ctx.Done():
+		}
+	}
+	err := server.Shutdown(ctx)
+	if err != nil {
+		err = errors.Join(err, server.Close())
+	}
+	err = errors.Join(err, a.stop(ctx))
+
+	l.mu.Lock()
+	l.server, l.stopErr = nil, err
+	l.mu.Unlock()
+	close(stopped)
+	return err
+}
+
+// start runs the start hooks in order, stopping at the first failure.
+func (a *Application) start(ctx context.Context) error {
+	l := &a.lifecycle
+	l.mu.Lock()
+	hooks := l.onStart
+	l.mu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stop runs the stop hooks in reverse order and collects their errors.
+func (a *Application) stop(ctx context.Context) error {
+	l := &a.lifecycle
+	l.mu.Lock()
+	hooks := l.onStop
+	l.mu.Unlock()
+	var err error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		err = errors.Join(err, hooks[i](ctx))
+	}
+	return err
+}
+
+// shuttingDown reports whether Shutdown was called on the running application.
+func (a *Application) shuttingDown() bool {
+	a.lifecycle.mu.Lock()
+	defer a.lifecycle.mu.Unlock()
+	return a.lifecycle.stopping
+}
+
+func (a *Application) shutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if a.timeouts.Shutdown <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, a.timeouts.Shutdown)
+}
@@ -0,0 +1,98 @@
+package worx
+
+import (
+	"context"
+	"errors"
+	"github.com/grahms/worx/router"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func startTestApplication(t *testing.T, app *Application, ctx context.Context) (string, <-chan error) {
+	NewRouter[specItem, specItem](app, "/lifecycle").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+		return nil, &specItem{}
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- app.serve(ctx, listener)
+	}()
+	return "http://" + listener.Addr().String(), done
+}
+
+func TestApplicationLifecycle(t *testing.T) {
+	t.Run("should run hooks around serving", func(t *testing.T) {
+		app := newTestApplication()
+		var calls []string
+		hook := func(name string) Hook {
+			return func(context.Context) error {
+				calls = append(calls, name)
+				return nil
+			}
+		}
+		app.OnStart(hook("start a"))
+		app.OnStart(hook("start b"))
+		app.OnStop(hook("stop a"))
+		app.OnStop(hook("stop b"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		_, done := startTestApplication(t, app, ctx)
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		assert.Nil(t, <-done)
+		assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, calls)
+	})
+
+	t.Run("should drain in-flight requests", func(t *testing.T) {
+		app := newTestApplication()
+		started := make(chan struct{})
+		NewRouter[specItem, specItem](app, "/slow").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			name := "done"
+			return nil, &specItem{Name: &name}
+		})
+		url, done := startTestApplication(t, app, context.Background())
+
+		responses := make(chan string, 1)
+		go func() {
+			resp, err := http.Get(url + "/api/slow/1")
+			if err != nil {
+				responses <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			responses <- string(body)
+		}()
+		<-started
+		assert.Nil(t, app.Shutdown(context.Background()))
+		assert.JSONEq(t, `{"name":"done"}`, <-responses)
+		assert.Nil(t, <-done)
+
+		_, err := http.Get(url + "/api/slow/1")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("should abort when a start hook fails", func(t *testing.T) {
+		app := newTestApplication()
+		stopped := false
+		app.OnStart(func(context.Context) error { return errors.New("cache unavailable") })
+		app.OnStop(func(context.Context) error {
+			stopped = true
+			return nil
+		})
+		_, done := startTestApplication(t, app, context.Background())
+		assert.EqualError(t, <-done, "cache unavailable")
+		assert.True(t, stopped)
+	})
+
+	t.Run("should shut down idle applications", func(t *testing.T) {
+		assert.Nil(t, newTestApplication().Shutdown(context.Background()))
+	})
+}
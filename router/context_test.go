@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContextAccessors(t *testing.T) {
+	ctx := context.Background()
+	_, ok := TraceIDFromContext(ctx)
+	assert.False(t, ok)
+
+	ctx = ContextWithTraceID(ctx, "trace-1")
+	ctx = ContextWithTenant(ctx, "acme")
+	ctx = ContextWithPrincipal(ctx, &Principal{Subject: "alice"})
+
+	traceID, _ := TraceIDFromContext(ctx)
+	tenant, _ := TenantFromContext(ctx)
+	principal, _ := PrincipalFromContext(ctx)
+	assert.Equal(t, "trace-1", traceID)
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, "alice", principal.Subject)
+}
+
+func TestRequestContextPropagation(t *testing.T) {
+	engine := newTestEngine()
+	engine.Use(func(c *gin.Context) {
+		ctx := ContextWithTraceID(c.Request.Context(), "abc")
+		c.Request = c.Request.WithContext(ContextWithTenant(ctx, "acme"))
+	})
+	endpoint := New[testItem, testItem]("/ctx", engine.Group(""))
+
+	var tenant string
+	calls := 0
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		calls++
+		tenant, _ = TenantFromContext(params.Context())
+		name := params.TraceID
+		return nil, &testItem{Name: &name}
+	})
+
+	t.Run("should expose upstream values", func(t *testing.T) {
+		w := doRequest(engine, http.MethodGet, "/ctx/1", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "acme", tenant)
+		assert.Contains(t, w.Body.String(), `"name":"abc"`)
+	})
+
+	t.Run("should not call processor for canceled requests", func(t *testing.T) {
+		calls = 0
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/ctx/1", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, statusClientClosedRequest, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("should answer expired deadlines with a timeout error", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, "/ctx/1", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUEST_TIMEOUT_ERROR")
+	})
+}
+
+func TestContextEndedDuringProcessing(t *testing.T) {
+	engine := newTestEngine()
+	var cancel context.CancelFunc
+	engine.Use(func(c *gin.Context) {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+	})
+	bus := NewMemoryEventBus()
+	endpoint := New[testItem, testItem]("/committed", engine.Group("")).PublishTo(bus)
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		cancel()
+		id := "1"
+		item.Id = &id
+		return nil, &item
+	})
+
+	w := doRequest(engine, http.MethodPost, "/committed", `{"name":"pen"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"id":"1","name":"pen"}`, w.Body.String())
+	assert.Len(t, bus.Events(), 1)
+}
@@ -0,0 +1,454 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrDispatcherStopped is returned when events are published to a dispatcher
+// that is not running.
+var ErrDispatcherStopped = errors.New("dispatcher is not running")
+
+// ErrDeadLetterNotFound is returned when no dead letter has the requested ID.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// SignatureHeader carries the HMAC-SHA256 signature of a delivered event body,
+// formatted as sha256=<hex digest>.
+const SignatureHeader = "X-Hub-Signature-256"
+
+// DeadLetter is an event that could not be delivered to a listener.
+type DeadLetter struct {
+	ID         string    `json:"id"`
+	ListenerID string    `json:"listenerId"`
+	Callback   string    `json:"callback"`
+	Event      *Event    `json:"event"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"lastError"`
+	FailedAt   time.Time `json:"failedAt"`
+}
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithSigningSecret signs every delivery with secret, see SignatureHeader.
+func WithSigningSecret(secret []byte) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.secret = secret
+	}
+}
+
+// WithRetries sets how many times a delivery is attempted and the backoff
+// between attempts, which doubles from baseDelay up to maxDelay.
+func WithRetries(maxAttempts int, baseDelay, maxDelay time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+		d.baseDelay = baseDelay
+		d.maxDelay = maxDelay
+	}
+}
+
+// WithListenerConcurrency limits the deliveries in flight to a single listener.
+func WithListenerConcurrency(n int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.concurrency = n
+	}
+}
+
+// WithDeadLetterLimit bounds the dead letter queue to limit letters, 1000 by
+// default. Once full the oldest letters are dropped to make room.
+func WithDeadLetterLimit(limit int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.deadLetterLimit = limit
+	}
+}
+
+// WithHTTPClient sets the client used to post events. Redirects it follows are
+// still checked against the hub callback policy, but unlike the default client
+// it does not refuse to dial private addresses.
+func WithHTTPClient(client *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// Dispatcher is an EventBus that posts each event to the callbacks of the hub
+// listeners whose query selects it. Deliveries run in the background and are
+// retried with exponential backoff; those that keep failing, or are rejected
+// with a 4xx status, end up in the dead letter queue.
+type Dispatcher struct {
+	hub             *Hub
+	client          *http.Client
+	secret          []byte
+	maxAttempts     int
+	baseDelay       time.Duration
+	maxDelay        time.Duration
+	concurrency     int
+	deadLetterLimit int
+
+	mu          sync.Mutex
+	slots       map[string]chan struct{}
+	deadLetters []*DeadLetter
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewDispatcher(hub *Hub, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		hub:         hub,
+		maxAttempts: 5,
+		baseDelay:   time.Second,
+		maxDelay:    time.Minute,
+		concurrency: 4,
+		slots:       make(map[string]chan struct{}),
+
+		deadLetterLimit: 1000,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.maxAttempts < 1 {
+		d.maxAttempts = 1
+	}
+	if d.concurrency < 1 {
+		d.concurrency = 1
+	}
+	if d.deadLetterLimit < 1 {
+		d.deadLetterLimit = 1
+	}
+	d.client = d.callbackClient(d.client)
+	return d
+}
+
+// callbackClient returns a copy of client checking every redirect against the
+// hub callback policy. Without a client, the default one also enforces
+// DenyPrivateCallbacks on the addresses it dials when the hub uses it.
+func (d *Dispatcher) callbackClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+		if d.hub.policy == nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+			transport.DialContext = dialer.DialContext
+			client.Transport = transport
+		}
+	}
+	checked := *client
+	checkRedirect := client.CheckRedirect
+	checked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if perr := d.hub.checkCallback(req.Context(), req.URL.String()); perr != nil {
+			return errors.New(perr.Message)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &checked
+}
+
+// Start makes the dispatcher accept events.
+func (d *Dispatcher) Start(context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ctx == nil {
+		d.ctx, d.cancel = context.WithCancel(context.Background())
+	}
+	return nil
+}
+
+// Stop stops accepting events and lets the scheduled deliveries, retries
+// included, run until ctx ends. Deliveries still pending then are cancelled and
+// moved to the dead letter queue.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	cancel := d.cancel
+	d.ctx, d.cancel = nil, nil
+	d.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Publish schedules the delivery of event to every matching listener.
+func (d *Dispatcher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(body, &doc); err != nil {
+		return err
+	}
+	listeners, err := d.hub.Listeners(ctx, doc)
+	if err != nil {
+		return err
+	}
+	for _, listener := range listeners {
+		if err = d.schedule(listener.ID, listener.Callback, event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) schedule(listenerID, callback string, event *Event, body []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ctx == nil {
+		return ErrDispatcherStopped
+	}
+	slot, ok := d.slots[listenerID]
+	if !ok {
+		slot = make(chan struct{}, d.concurrency)
+		d.slots[listenerID] = slot
+	}
+	d.wg.Add(1)
+	go d.deliver(d.ctx, slot, listenerID, callback, event, body)
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, slot chan struct{}, listenerID, callback string, event *Event, body []byte) {
+	defer d.wg.Done()
+	var err error
+	attempt := 0
+	for attempt < d.maxAttempts {
+		if attempt > 0 {
+			select {
+			case <-time.After(d.backoff(attempt)):
+			case <-ctx.Done():
+				d.deadLetter(listenerID, callback, event, attempt, ctx.Err())
+				return
+			}
+		}
+		select {
+		case slot <- struct{}{}:
+		case <-ctx.Done():
+			d.deadLetter(listenerID, callback, event, attempt, ctx.Err())
+			return
+		}
+		attempt++
+		var retry bool
+		retry, err = d.post(ctx, callback, event, body)
+		<-slot
+		if err == nil {
+			return
+		}
+		if !retry {
+			break
+		}
+	}
+	d.deadLetter(listenerID, callback, event, attempt, err)
+}
+
+// post sends one delivery attempt. It reports whether a failure is worth
+// retrying.
+func (d *Dispatcher) post(ctx context.Context, callback string, event *Event, body []byte) (bool, error) {
+	// The callback host may resolve elsewhere than when the listener registered.
+	if perr := d.hub.checkCallback(ctx, callback); perr != nil {
+		return false, errors.New(perr.Message)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.EventID)
+	req.Header.Set("X-Event-Type", event.EventType)
+	if len(d.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(d.secret, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("callback answered %d", resp.StatusCode)
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, err
+}
+
+// backoff returns the wait before the given retry, doubling from baseDelay up
+// to maxDelay with up to half of it randomised.
+func (d *Dispatcher) backoff(retry int) time.Duration {
+	delay := d.baseDelay
+	for i := 1; i < retry && delay < d.maxDelay; i++ {
+		delay *= 2
+	}
+	if d.maxDelay > 0 && delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int64N(half+1))
+	}
+	return delay
+}
+
+func (d *Dispatcher) deadLetter(listenerID, callback string, event *Event, attempts int, err error) {
+	letter := &DeadLetter{
+		ID:         newID(),
+		ListenerID: listenerID,
+		Callback:   callback,
+		Event:      event,
+		Attempts:   attempts,
+		FailedAt:   time.Now().UTC(),
+	}
+	if err != nil {
+		letter.LastError = err.Error()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queueDeadLetter(letter)
+}
+
+// queueDeadLetter appends letter to the queue, dropping the oldest letters
+// beyond the limit. d.mu must be held.
+func (d *Dispatcher) queueDeadLetter(letter *DeadLetter) {
+	d.deadLetters = append(d.deadLetters, letter)
+	if excess := len(d.deadLetters) - d.deadLetterLimit; excess > 0 {
+		d.deadLetters = append(d.deadLetters[:0], d.deadLetters[excess:]...)
+	}
+}
+
+// DeadLetters returns the undelivered events, oldest first.
+func (d *Dispatcher) DeadLetters() []*DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*DeadLetter(nil), d.deadLetters...)
+}
+
+// DeleteDeadLetter discards a dead letter.
+func (d *Dispatcher) DeleteDeadLetter(id string) error {
+	_, err := d.takeDeadLetter(id)
+	return err
+}
+
+// Redeliver removes a dead letter from the queue and schedules its delivery
+// again.
+func (d *Dispatcher) Redeliver(id string) error {
+	letter, err := d.takeDeadLetter(id)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(letter.Event)
+	if err != nil {
+		return err
+	}
+	if err = d.schedule(letter.ListenerID, letter.Callback, letter.Event, body); err != nil {
+		d.mu.Lock()
+		d.queueDeadLetter(letter)
+		d.mu.Unlock()
+	}
+	return err
+}
+
+func (d *Dispatcher) takeDeadLetter(id string) (*DeadLetter, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, letter := range d.deadLetters {
+		if letter.ID == id {
+			d.deadLetters = append(d.deadLetters[:i], d.deadLetters[i+1:]...)
+			return letter, nil
+		}
+	}
+	return nil, ErrDeadLetterNotFound
+}
+
+// Sign returns the SignatureHeader value for body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature, a SignatureHeader value, matches
+// body. Listeners use it to authenticate deliveries.
+func VerifySignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// HandleDeadLetters exposes the dead letter queue of d on endpoint:
+// GET lists it, DELETE {path}/:id discards a letter and
+// POST {path}/:id/redeliver schedules it again.
+func (d *Dispatcher) HandleDeadLetters(endpoint *APIEndpoint[DeadLetter, DeadLetter], opts ...HandleOption) {
+	opts = append(opts, WithTags([]string{"admin"}))
+	endpoint.HandleList("", func(params *RequestParams, limit int, offset int) ([]*DeadLetter, *Err, int, int) {
+		matching := make([]*DeadLetter, 0)
+		for _, letter := range d.DeadLetters() {
+			if params.Filter.Match(endpoint.convertToMap(letter)) {
+				matching = append(matching, letter)
+			}
+		}
+		total := len(matching)
+		start := min(max(offset, 0), total)
+		end := total
+		if limit > 0 {
+			end = min(start+limit, total)
+		}
+		return matching[start:end], nil, total, end - start
+	}, opts...)
+
+	endpoint.HandleDelete("/:id", func(params *RequestParams) *Err {
+		return deadLetterErr(d.DeleteDeadLetter(params.PathParams["id"]))
+	}, opts...)
+
+	endpoint.HandleCreateWithoutBody("/:id/redeliver", func(params *RequestParams) (*Err, *DeadLetter) {
+		id := params.PathParams["id"]
+		var letter *DeadLetter
+		for _, candidate := range d.DeadLetters() {
+			if candidate.ID == id {
+				letter = candidate
+			}
+		}
+		if perr := deadLetterErr(d.Redeliver(id)); perr != nil {
+			return perr, nil
+		}
+		return nil, letter
+	}, append(opts, WithStatusCode(http.StatusAccepted))...)
+}
+
+func deadLetterErr(err error) *Err {
+	if errors.Is(err, ErrDeadLetterNotFound) {
+		var e *Error
+		code, notFound := e.ResourceNotFound()
+		return &Err{StatusCode: code, ErrCode: notFound.Code, ErrReason: notFound.Reason, Message: notFound.Message}
+	}
+	if err != nil {
+		return hubStoreErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDispatcher(t *testing.T, hub *Hub, opts ...DispatcherOption) *Dispatcher {
+	opts = append([]DispatcherOption{WithRetries(3, time.Millisecond, 5*time.Millisecond)}, opts...)
+	d := NewDispatcher(hub, opts...)
+	assert.Nil(t, d.Start(context.Background()))
+	return d
+}
+
+func testEvent(eventType string) *Event {
+	return &Event{
+		EventID:   newID(),
+		EventTime: time.Now().UTC(),
+		EventType: eventType,
+		Event:     map[string]any{"product": map[string]any{"name": "pen"}},
+	}
+}
+
+func TestDispatcherDelivery(t *testing.T) {
+	secret := []byte("s3cret")
+	var mu sync.Mutex
+	var received []*Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, VerifySignature(secret, body, r.Header.Get(SignatureHeader)))
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		mu.Lock()
+		received = append(received, &event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	hub := NewHub(nil, WithCallbackPolicy(AllowAllCallbacks))
+	_, perr := hub.Register(context.Background(), receiver.URL, "eventType=ProductCreateEvent")
+	assert.Nil(t, perr)
+	d := newTestDispatcher(t, hub, WithSigningSecret(secret))
+
+	assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+	assert.Nil(t, d.Publish(context.Background(), testEvent("ProductDeleteEvent")))
+	assert.Nil(t, d.Stop(context.Background()))
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, "ProductCreateEvent", received[0].EventType)
+	assert.Empty(t, d.DeadLetters())
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	hub := NewHub(nil, WithCallbackPolicy(AllowAllCallbacks))
+	_, _ = hub.Register(context.Background(), receiver.URL, "")
+	d := newTestDispatcher(t, hub)
+
+	assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+	assert.Nil(t, d.Stop(context.Background()))
+
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Empty(t, d.DeadLetters())
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	var calls atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer receiver.Close()
+
+	hub := NewHub(nil, WithCallbackPolicy(AllowAllCallbacks))
+	listener, _ := hub.Register(context.Background(), receiver.URL, "")
+
+	t.Run("should dead letter after the last attempt", func(t *testing.T) {
+		d := newTestDispatcher(t, hub)
+		assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+		assert.Nil(t, d.Stop(context.Background()))
+
+		assert.Equal(t, int32(3), calls.Load())
+		letters := d.DeadLetters()
+		assert.Len(t, letters, 1)
+		assert.Equal(t, listener.ID, letters[0].ListenerID)
+		assert.Equal(t, 3, letters[0].Attempts)
+		assert.Equal(t, "callback answered 500", letters[0].LastError)
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		calls.Store(0)
+		status.Store(http.StatusGone)
+		d := newTestDispatcher(t, hub)
+		assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+		assert.Nil(t, d.Stop(context.Background()))
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Len(t, d.DeadLetters(), 1)
+	})
+
+	t.Run("should redeliver dead letters", func(t *testing.T) {
+		status.Store(http.StatusOK)
+		d := newTestDispatcher(t, hub, WithRetries(1, time.Millisecond, time.Millisecond))
+		d.deadLetter(listener.ID, listener.Callback, testEvent("ProductCreateEvent"), 1, nil)
+		letter := d.DeadLetters()[0]
+
+		assert.Nil(t, d.Redeliver(letter.ID))
+		assert.Nil(t, d.Stop(context.Background()))
+		assert.Empty(t, d.DeadLetters())
+		assert.Equal(t, ErrDeadLetterNotFound, d.Redeliver(letter.ID))
+	})
+
+	t.Run("should drop the oldest dead letters beyond the limit", func(t *testing.T) {
+		d := NewDispatcher(hub, WithDeadLetterLimit(2))
+		for _, eventType := range []string{"ProductCreateEvent", "ProductAttributeValueChangeEvent", "ProductDeleteEvent"} {
+			d.deadLetter(listener.ID, listener.Callback, testEvent(eventType), 1, nil)
+		}
+
+		letters := d.DeadLetters()
+		assert.Len(t, letters, 2)
+		assert.Equal(t, "ProductAttributeValueChangeEvent", letters[0].Event.EventType)
+		assert.Equal(t, "ProductDeleteEvent", letters[1].Event.EventType)
+	})
+
+	t.Run("should refuse events once stopped", func(t *testing.T) {
+		d := NewDispatcher(hub)
+		assert.Equal(t, ErrDispatcherStopped, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+	})
+}
+
+func TestDispatcherCallbackPolicy(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer receiver.Close()
+
+	var denied atomic.Bool
+	hub := NewHub(nil, WithCallbackPolicy(func(context.Context, *url.URL) error {
+		if denied.Load() {
+			return errors.New("denied")
+		}
+		return nil
+	}))
+	_, perr := hub.Register(context.Background(), receiver.URL, "")
+	assert.Nil(t, perr)
+
+	denied.Store(true)
+	d := newTestDispatcher(t, hub)
+	assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+	assert.Nil(t, d.Stop(context.Background()))
+
+	assert.Equal(t, int32(0), calls.Load())
+	letters := d.DeadLetters()
+	assert.Len(t, letters, 1)
+	assert.Equal(t, 1, letters[0].Attempts)
+	assert.Contains(t, letters[0].LastError, "denied")
+}
+
+func TestDispatcherRedirects(t *testing.T) {
+	var denied atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		denied.Add(1)
+	}))
+	defer internal.Close()
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirecting.Close()
+
+	allowed, _ := url.Parse(redirecting.URL)
+	hub := NewHub(nil, WithCallbackPolicy(func(_ context.Context, callback *url.URL) error {
+		if callback.Host != allowed.Host {
+			return errors.New("denied")
+		}
+		return nil
+	}))
+	_, perr := hub.Register(context.Background(), redirecting.URL, "")
+	assert.Nil(t, perr)
+
+	d := newTestDispatcher(t, hub)
+	assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+	assert.Nil(t, d.Stop(context.Background()))
+
+	assert.Equal(t, int32(0), denied.Load())
+	letters := d.DeadLetters()
+	assert.Len(t, letters, 1)
+	assert.Contains(t, letters[0].LastError, "denied")
+}
+
+func TestDialPublicOnly(t *testing.T) {
+	assert.Nil(t, dialPublicOnly("tcp", "203.0.113.10:443", nil))
+	for _, address := range []string{"127.0.0.1:80", "10.1.2.3:80", "169.254.169.254:80", "[::1]:80", "[fd00:ec2::254]:80"} {
+		assert.NotNil(t, dialPublicOnly("tcp", address, nil), address)
+	}
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+	d := NewDispatcher(NewHub(nil))
+	_, err := d.client.Post(receiver.URL, "application/json", nil)
+	assert.ErrorContains(t, err, "not publicly routable")
+}
+
+func TestDispatcherConcurrencyLimit(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	hub := NewHub(nil, WithCallbackPolicy(AllowAllCallbacks))
+	_, _ = hub.Register(context.Background(), receiver.URL, "")
+	d := newTestDispatcher(t, hub, WithListenerConcurrency(2))
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, d.Publish(context.Background(), testEvent("ProductCreateEvent")))
+	}
+	assert.Nil(t, d.Stop(context.Background()))
+
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestHandleDeadLetters(t *testing.T) {
+	engine := newTestEngine()
+	d := NewDispatcher(NewHub(nil))
+	d.HandleDeadLetters(New[DeadLetter, DeadLetter]("/admin/deadLetters", engine.Group("/v1")))
+	d.deadLetter("l1", "http://localhost/a", testEvent("ProductCreateEvent"), 5, nil)
+	d.deadLetter("l2", "http://localhost/b", testEvent("ProductDeleteEvent"), 5, nil)
+
+	w := doRequest(engine, http.MethodGet, "/v1/admin/deadLetters?listenerId=l2", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var letters []DeadLetter
+	_ = json.Unmarshal(w.Body.Bytes(), &letters)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "ProductDeleteEvent", letters[0].Event.EventType)
+
+	w = doRequest(engine, http.MethodDelete, "/v1/admin/deadLetters/"+letters[0].ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, d.DeadLetters(), 1)
+
+	w = doRequest(engine, http.MethodDelete, "/v1/admin/deadLetters/"+letters[0].ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(engine, http.MethodGet, "/v1/admin/deadLetters?offset=-1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_OFFSET_ERROR")
+
+	w = doRequest(engine, http.MethodGet, "/v1/admin/deadLetters?offset=5", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
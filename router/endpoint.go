@@ -3,9 +3,13 @@ package router
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/grahms/godantic"
 	"io"
+	"log/slog"
+	"reflect"
+	"slices"
 
 	"net/http"
 	"strconv"
@@ -24,10 +28,20 @@ func NewAPIEndpointGroup[Req any, Resp any](path string, group *gin.RouterGroup)
 }
 
 type APIEndpoint[Req any, Resp any] struct {
-	Path       string
-	Router     *gin.RouterGroup
-	validator  *Validation
-	dataBinder godantic.Validate
+	Path   string
+	Router *gin.RouterGroup
+	// EventResource names the resource in published events, ex: productOrder.
+	EventResource string
+	validator     *Validation
+	dataBinder    godantic.Validate
+	events        EventBus
+	middlewares   []Middleware
+	schemes       map[string]*SecurityScheme
+}
+
+var fieldsParam = AllowedFields{
+	Name:        "fields",
+	Description: "fields to be selected, dotted paths select nested fields ex: fields=id,name,specification.name",
 }
 
 type RequestParams struct {
@@ -35,6 +49,26 @@ type RequestParams struct {
 	Headers    map[string]string
 	PathParams map[string]string
 	TraceID    string
+	// Principal is the caller authenticated by the endpoint security, if any.
+	Principal *Principal
+	// Logger is the request scoped logger set by RequestLogger, slog.Default()
+	// otherwise.
+	Logger *slog.Logger
+	Filter Filter
+	Sort   []SortKey
+	ctx    context.Context
+	// bound holds the parameters bound with WithParams, read through Params.
+	bound any
+}
+
+// Context returns the context of the incoming request. It is canceled when the
+// client disconnects and carries any deadline, trace ID, principal and tenant
+// set by upstream middleware.
+func (p *RequestParams) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
 }
 
 func New[In, Out any](path string, group *gin.RouterGroup) *APIEndpoint[In, Out] {
@@ -50,120 +84,261 @@ func setTags(path string, config *EndpointConfigs) {
 	config.GeneratedTags = []string{tag}
 }
 func (r *APIEndpoint[Req, Resp]) HandleCreate(uri string, processRequest func(Req, *RequestParams) (*Err, *Resp), opts ...HandleOption) {
-	config := getConfigs(opts...)
+	config := r.configs(append(opts, WithAllowedParams([]AllowedFields{fieldsParam}))...)
 	setTags(r.Path, config)
-	registerEndpoint(r.Router.BasePath()+r.Path+uri, "POST", new(Req), new(Resp), *config, opts...)
-	statusCode := http.StatusCreated
-	if config.StatusCode != nil {
-		statusCode = *config.StatusCode
-	}
-	r.Router.POST(r.Path+uri, func(c *gin.Context) {
+	statusCode := config.resolveStatusCode(http.StatusCreated)
+	registerEndpoint(r.Router.BasePath()+r.Path+uri, "POST", new(Req), new(Resp), *config)
+	r.handle(http.MethodPost, uri, config, func(c *gin.Context) {
 
 		params := r.extractRequestParams(c)
 		if statusCode, exception := r.validateJSONContentType(c); exception != nil {
-
-			c.JSON(statusCode, exception)
+			r.fail(c, validationFailure, statusCode, *exception)
 			return
 		}
 
 		var requestBody Req
 		if err := r.bindJSON(c.Request.Body, &requestBody); err != nil {
 			code, e := r.validator.InputErr(err)
-
-			c.JSON(code, e)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		fields, ok := r.selectedFields(c)
+		if !ok {
 			return
 		}
 
+		if r.contextDone(c) {
+			return
+		}
 		perr, response := processRequest(requestBody, &params)
 		if perr != nil {
 			code, e := r.validator.ProcessorErr(perr)
-
-			c.JSON(code, e)
+			r.fail(c, processorFailure, code, e)
 			return
 		}
 
-		c.JSON(statusCode, r.convertToMap(*response))
+		r.writeResource(c, statusCode, fields, response)
+		r.publish(c, CreateEvent, r.convertToMap(response), nil)
 		return
 	})
 }
 
 func (r *APIEndpoint[Req, Resp]) HandleRead(pathSuffix string, processRequest func(*RequestParams) (*Err, *Resp), opts ...HandleOption) {
-	config := getConfigs(opts...)
+	config := r.configs(append(opts, WithAllowedParams([]AllowedFields{fieldsParam}))...)
 	setTags(r.Path, config)
-	registerEndpoint(r.Router.BasePath()+r.Path+pathSuffix, "GET", new(Req), new(Resp), *config, opts...)
-	statusCode := http.StatusOK
-	if config.StatusCode != nil {
-		statusCode = *config.StatusCode
-	}
-	r.Router.GET(r.Path+pathSuffix, func(c *gin.Context) {
+	statusCode := config.resolveStatusCode(http.StatusOK)
+	registerEndpoint(r.Router.BasePath()+r.Path+pathSuffix, "GET", new(Req), new(Resp), *config)
+	r.handle(http.MethodGet, pathSuffix, config, func(c *gin.Context) {
 		reqValues := r.extractRequestParams(c)
+		fields, ok := r.selectedFields(c)
+		if !ok {
+			return
+		}
+		if r.contextDone(c) {
+			return
+		}
 		perr, resp := processRequest(&reqValues)
 		// handle processor error
 		if perr != nil {
 			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, processorFailure, code, e)
+			return
+		}
+		r.writeResource(c, statusCode, fields, resp)
+		return
+	})
+}
 
-			c.JSON(code, e)
+// HandleUpdate registers a PATCH handler. A plain application/json body is bound
+// as a partial update, ignoring required fields. When WithPatchLoader is given the
+// handler also accepts application/merge-patch+json and application/json-patch+json,
+// applies the patch to the loaded resource and validates the result in full.
+func (r *APIEndpoint[Req, Resp]) HandleUpdate(pathString string, requestProcessor func(id string, reqBody Req, params *RequestParams) (*Err, *Resp), opts ...HandleOption) {
+	binder := godantic.Validate{}
+	binder.IgnoreRequired = true
+	binder.IgnoreMinLen = true
+
+	config := r.configs(append(opts, WithAllowedParams([]AllowedFields{fieldsParam}))...)
+	setTags(r.Path, config)
+	config.Consumes = []string{MediaTypeJSON}
+	if config.patchLoader != nil {
+		config.Consumes = append(config.Consumes, MediaTypeMergePatch, MediaTypeJSONPatch)
+	}
+	statusCode := config.resolveStatusCode(http.StatusOK)
+	registerEndpoint(r.Router.BasePath()+r.Path+pathString, "PATCH", new(Req), new(Resp), *config)
+
+	r.handle(http.MethodPatch, pathString, config, func(c *gin.Context) {
+		mediaType := c.ContentType()
+		if config.patchLoader == nil {
+			if statusCode, exception := r.validateJSONContentType(c); exception != nil {
+				r.fail(c, validationFailure, statusCode, *exception)
+				return
+			}
+		} else if !slices.Contains(config.Consumes, mediaType) {
+			statusCode, e := r.validator.unsupportedMediaType(config.Consumes)
+			r.fail(c, validationFailure, statusCode, e)
 			return
 		}
-		fields := strings.Replace(c.Query("fields"), " ", "", -1)
-		fieldsList := make([]string, 0)
-		if fields != "" {
-			fieldsList = strings.Split(fields, ",")
 
+		var reqBody Req
+		reqValues := r.extractRequestParams(c)
+		fields, ok := r.selectedFields(c)
+		if !ok {
+			return
 		}
-		respWithFields, err := fieldSelector(fieldsList, r.convertToMap(*resp))
+		requestDataBytes, err := io.ReadAll(c.Request.Body)
 		if err != nil {
-			code, e := r.validator.ProcessorErr(err)
+			code, e := r.validator.InputErr(err)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		if mediaType == MediaTypeJSON {
+			err = binder.BindJSON(requestDataBytes, &reqBody)
+		} else {
+			if r.contextDone(c) {
+				return
+			}
+			perr, patched := r.applyPatch(config.patchLoader, mediaType, requestDataBytes, &reqValues)
+			if perr != nil {
+				code, e := r.validator.ProcessorErr(perr)
+				r.fail(c, processorFailure, code, e)
+				return
+			}
+			err = r.dataBinder.BindJSON(patched, &reqBody)
+		}
+		if err != nil {
+			code, e := r.validator.InputErr(err)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		id := c.Param("id")
 
-			c.JSON(code, e)
+		if r.contextDone(c) {
+			return
+		}
+		perr, resp := requestProcessor(id, reqBody, &reqValues)
+		if perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, processorFailure, code, e)
 			return
 		}
 
-		c.JSON(statusCode, respWithFields)
+		r.writeResource(c, statusCode, fields, resp)
+		r.publish(c, AttributeValueChangeEvent, r.convertToMap(resp), changedAttributes(mediaType, requestDataBytes))
 		return
 	})
 }
 
-func (r *APIEndpoint[Req, Resp]) HandleUpdate(pathString string, requestProcessor func(id string, reqBody Req, params *RequestParams) (*Err, *Resp), opts ...HandleOption) {
-	binder := godantic.Validate{}
-	binder.IgnoreRequired = true
-	binder.IgnoreMinLen = true
+// applyPatch loads the current resource and returns it, patched, as JSON ready
+// to be bound into Req.
+func (r *APIEndpoint[Req, Resp]) applyPatch(loader func(*RequestParams) (*Err, any), mediaType string, patch []byte, params *RequestParams) (*Err, []byte) {
+	perr, current := loader(params)
+	if perr != nil {
+		return perr, nil
+	}
+	if current == nil || reflect.ValueOf(current).IsNil() {
+		var e *Error
+		code, notFound := e.ResourceNotFound()
+		return &Err{StatusCode: code, ErrCode: notFound.Code, ErrReason: notFound.Reason, Message: notFound.Message}, nil
+	}
+	doc := r.convertToMap(current)
+	projectOnto(reflect.TypeOf(new(Req)), doc)
+
+	patched, perr := applyPatch(mediaType, doc, patch)
+	if perr != nil {
+		return perr, nil
+	}
+	data, err := json.Marshal(patched)
+	if err != nil {
+		return invalidPatchErr(err.Error()), nil
+	}
+	return nil, data
+}
+
+// HandleReplace registers a PUT handler that replaces the whole resource. Unlike
+// HandleUpdate the body is bound with full validation, so required fields must be
+// present. Every path parameter is available through params.PathParams.
+func (r *APIEndpoint[Req, Resp]) HandleReplace(pathString string, requestProcessor func(reqBody Req, params *RequestParams) (*Err, *Resp), opts ...HandleOption) {
+	r.handlePut(pathString, func(reqBody Req, params *RequestParams) (*Err, *Resp, bool) {
+		perr, resp := requestProcessor(reqBody, params)
+		return perr, resp, false
+	}, opts...)
+}
+
+// HandleUpsert registers a PUT handler that creates the resource when it does not
+// exist yet. The processor reports whether the resource was created, in which case
+// the response status is 201 instead of 200.
+func (r *APIEndpoint[Req, Resp]) HandleUpsert(pathString string, requestProcessor func(reqBody Req, params *RequestParams) (perr *Err, resp *Resp, created bool), opts ...HandleOption) {
+	r.handlePut(pathString, requestProcessor, append(opts, withSuccessCodes(http.StatusCreated))...)
+}
 
-	config := getConfigs(opts...)
+func (r *APIEndpoint[Req, Resp]) handlePut(pathString string, requestProcessor func(Req, *RequestParams) (*Err, *Resp, bool), opts ...HandleOption) {
+	config := r.configs(append(opts, WithAllowedParams([]AllowedFields{fieldsParam}))...)
 	setTags(r.Path, config)
-	registerEndpoint(r.Router.BasePath()+r.Path+pathString, "PATCH", new(Req), new(Resp), *config, opts...)
-	statusCode := http.StatusOK
+	statusCode := config.resolveStatusCode(http.StatusOK)
+	registerEndpoint(r.Router.BasePath()+r.Path+pathString, "PUT", new(Req), new(Resp), *config)
 
-	r.Router.PATCH(r.Path+pathString, func(c *gin.Context) {
+	r.handle(http.MethodPut, pathString, config, func(c *gin.Context) {
 		if statusCode, exception := r.validateJSONContentType(c); exception != nil {
-			c.JSON(statusCode, exception)
+			r.fail(c, validationFailure, statusCode, *exception)
 			return
 		}
 
-		var reqBody Req
-		reqValues := r.extractRequestParams(c)
+		params := r.extractRequestParams(c)
 		requestDataBytes, err := io.ReadAll(c.Request.Body)
-		if err = binder.BindJSON(requestDataBytes, &reqBody); err != nil {
+		if err != nil {
 			code, e := r.validator.InputErr(err)
-
-			c.JSON(code, e)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		var reqBody Req
+		if err := r.dataBinder.BindJSON(requestDataBytes, &reqBody); err != nil {
+			code, e := r.validator.InputErr(err)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		fields, ok := r.selectedFields(c)
+		if !ok {
 			return
 		}
-		id := c.Param("id")
 
-		perr, resp := requestProcessor(id, reqBody, &reqValues)
+		if r.contextDone(c) {
+			return
+		}
+		perr, resp, created := requestProcessor(reqBody, &params)
 		if perr != nil {
 			code, e := r.validator.ProcessorErr(perr)
-
-			c.JSON(code, e)
+			r.fail(c, processorFailure, code, e)
 			return
 		}
 
-		c.JSON(statusCode, r.convertToMap(*resp))
-		return
+		if created {
+			r.writeResource(c, http.StatusCreated, fields, resp)
+			r.publish(c, CreateEvent, r.convertToMap(resp), nil)
+			return
+		}
+		r.writeResource(c, statusCode, fields, resp)
+		r.publish(c, AttributeValueChangeEvent, r.convertToMap(resp), changedAttributes(MediaTypeJSON, requestDataBytes))
 	})
 }
 
+// selectedFields parses the fields query parameter against Resp, answering
+// unknown fields with 400. It reports false when the request was answered.
+func (r *APIEndpoint[Req, Resp]) selectedFields(c *gin.Context) ([]string, bool) {
+	fields, perr := parseFields(c.Query("fields"), reflect.TypeOf(new(Resp)))
+	if perr != nil {
+		code, e := r.validator.ProcessorErr(perr)
+		r.fail(c, validationFailure, code, e)
+		return nil, false
+	}
+	return fields, true
+}
+
+// writeResource writes resp, projected onto the selected fields.
+func (r *APIEndpoint[Req, Resp]) writeResource(c *gin.Context, statusCode int, fields []string, resp *Resp) {
+	c.JSON(statusCode, fieldSelector(fields, r.convertToMap(*resp)))
+}
+
 func (r *APIEndpoint[Req, Resp]) convertToMap(obj interface{}) map[string]interface{} {
 	data := make(map[string]interface{})
 	jsonBytes, _ := json.Marshal(obj)
@@ -172,85 +347,100 @@ func (r *APIEndpoint[Req, Resp]) convertToMap(obj interface{}) map[string]interf
 	return data
 }
 
+// HandleList registers a list handler whose processor returns the page items,
+// an error, the total number of matching resources and the number of returned
+// items. New code should prefer HandleListPage.
 func (r *APIEndpoint[Req, Resp]) HandleList(pathString string, requestProcessor func(params *RequestParams, limit int, offset int) ([]*Resp, *Err, int, int), opts ...HandleOption) {
-	opts = append(opts, WithAllowedParams([]AllowedFields{
-		{
-			Name:        "limit",
-			Description: "page limit",
-		},
-		{
-			Name:        "offset",
-			Description: "page number",
-		},
-		{
-			Name:        "fields",
-			Description: "fields to be selected ex: fields=id,name",
-		},
-	}))
-	config := getConfigs(opts...)
+	r.HandleListPage(pathString, func(params *RequestParams, limit int, offset int) (*Err, *Page[Resp]) {
+		items, perr, total, _ := requestProcessor(params, limit, offset)
+		if perr != nil {
+			return perr, nil
+		}
+		page := &Page[Resp]{Items: items}
+		if total >= 0 {
+			page.Total = &total
+		}
+		return nil, page
+	}, opts...)
+}
+
+// HandleListPage registers a list handler. The response is always a JSON array,
+// answered with 200 when it holds the whole collection and 206 Partial Content
+// when more results exist before or after it.
+func (r *APIEndpoint[Req, Resp]) HandleListPage(pathString string, requestProcessor func(params *RequestParams, limit int, offset int) (*Err, *Page[Resp]), opts ...HandleOption) {
+	config := r.configs(opts...)
 	setTags(r.Path, config)
+	pagination := config.Pagination.withDefaults()
+	config.Pagination = pagination
+	config.successCodes = append(config.successCodes, http.StatusPartialContent)
+	config.AllowedParams = append(config.AllowedParams, pagination.params()...)
+	config.AllowedParams = append(config.AllowedParams, fieldsParam, AllowedFields{
+		Name:        "sort",
+		Description: "fields to sort by, prefixed with - for descending order ex: sort=-price,name",
+	})
+	exempt := make(map[string]bool)
+	for _, param := range config.AllowedParams {
+		exempt[param.Name] = true
+	}
+	config.AllowedParams = append(config.AllowedParams, filterParams(reflect.TypeOf(new(Resp)))...)
 
+	statusCode := config.resolveStatusCode(http.StatusOK)
 	registerEndpoint(r.Router.BasePath()+r.Path+pathString, "GET", new(Req), new(Resp), *config)
-	statusCode := http.StatusOK
-	if config.StatusCode != nil {
-		statusCode = *config.StatusCode
-	}
-	r.Router.GET(r.Path+pathString, func(c *gin.Context) {
+	r.handle(http.MethodGet, pathString, config, func(c *gin.Context) {
 		params := r.extractRequestParams(c)
-		limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, &Err{
-				ErrCode:    "INVALID_LIMIT_ERROR",
-				ErrReason:  "Bad Request",
-				StatusCode: http.StatusBadRequest,
-				Message:    "the query <limit> should be a valid integer",
-			})
+		filter, perr := parseFilter(c.Request.URL.Query(), reflect.TypeOf(new(Resp)), exempt)
+		if perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, validationFailure, code, e)
 			return
 		}
-
-		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0")) // default offset is 0
-		if err != nil {
-			c.JSON(http.StatusBadRequest, &Err{
-				ErrCode:    "INVALID_OFFSET_ERROR",
-				ErrReason:  "Bad Request",
-				StatusCode: http.StatusBadRequest,
-				Message:    "the query <offset> should be a valid integer",
-			})
+		params.Filter = filter
+		sortKeys, perr := parseSort(c.Query("sort"), reflect.TypeOf(new(Resp)))
+		if perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		params.Sort = sortKeys
+		fields, ok := r.selectedFields(c)
+		if !ok {
 			return
 		}
 
-		resp, perr, amount, total := requestProcessor(&params, limit, offset)
+		limit, offset, perr := pagination.page(c)
 		if perr != nil {
 			code, e := r.validator.ProcessorErr(perr)
-
-			c.JSON(code, e)
+			r.fail(c, validationFailure, code, e)
 			return
 		}
 
-		var responseMaps []map[string]interface{}
-		fields := strings.Replace(c.Query("fields"), " ", "", -1)
-		fieldsList := make([]string, 0)
-		if fields != "" {
-			fieldsList = strings.Split(fields, ",")
+		if r.contextDone(c) {
+			return
+		}
+		perr, page := requestProcessor(&params, limit, offset)
+		if perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, processorFailure, code, e)
+			return
+		}
+		if page == nil {
+			page = &Page[Resp]{}
 		}
 
-		for _, res := range resp {
-			resp := res
-			respMap := r.convertToMap(*resp)
-			respWithFields, err := fieldSelector(fieldsList, respMap)
-			if err != nil {
-				code, e := r.validator.ProcessorErr(err)
-				c.JSON(code, e)
-				return
-			}
-			responseMaps = append(responseMaps, respWithFields)
+		responseMaps := make([]map[string]interface{}, 0, len(page.Items))
+		for _, res := range page.Items {
+			responseMaps = append(responseMaps, fieldSelector(fields, r.convertToMap(*res)))
 		}
 
-		c.Header("X-Total-Count", strconv.Itoa(amount))
-		c.Header("X-Result-Count", strconv.Itoa(total))
+		hasMore := page.hasMore(offset)
+		if page.Total != nil {
+			c.Header("X-Total-Count", strconv.Itoa(*page.Total))
+		}
+		c.Header("X-Result-Count", strconv.Itoa(len(page.Items)))
+		c.Header("Link", pagination.links(c.Request.URL, limit, offset, hasMore))
 		c.Header("trace-id", params.TraceID)
-		if len(resp) == 0 {
-			c.JSON(http.StatusOK, resp)
+		if hasMore || (offset > 0 && len(page.Items) > 0) {
+			c.JSON(http.StatusPartialContent, responseMaps)
 			return
 		}
 		c.JSON(statusCode, responseMaps)
@@ -279,7 +469,19 @@ func (r *APIEndpoint[Req, Resp]) extractRequestParams(c *gin.Context) RequestPar
 		Query:      make(map[string]any),
 		Headers:    make(map[string]string),
 		PathParams: make(map[string]string),
+		ctx:        c.Request.Context(),
 	}
+	if traceID, ok := TraceIDFromContext(params.ctx); ok {
+		params.TraceID = traceID
+	}
+	if principal, ok := PrincipalFromContext(params.ctx); ok {
+		params.Principal = principal
+	}
+	params.Logger = slog.Default()
+	if logger, ok := LoggerFromContext(params.ctx); ok {
+		params.Logger = logger
+	}
+	params.bound, _ = c.Get(paramsKey)
 
 	for _, p := range c.Params {
 		param := p
@@ -300,47 +502,83 @@ func (r *APIEndpoint[Req, Resp]) extractRequestParams(c *gin.Context) RequestPar
 }
 
 func (r *APIEndpoint[Req, Resp]) HandleCreateWithoutBody(uri string, processRequest func(*RequestParams) (*Err, *Resp), opts ...HandleOption) {
-	config := getConfigs(opts...)
+	config := r.configs(append(opts, WithAllowedParams([]AllowedFields{fieldsParam}))...)
 	setTags(r.Path, config)
+	statusCode := config.resolveStatusCode(http.StatusCreated)
 	registerEndpoint(r.Router.BasePath()+r.Path+uri, "POST", new(Req), new(Resp), *config)
-	statusCode := http.StatusCreated
-	if config.StatusCode != nil {
-		statusCode = *config.StatusCode
-	}
-	r.Router.POST(r.Path+uri, func(c *gin.Context) {
+	r.handle(http.MethodPost, uri, config, func(c *gin.Context) {
 		params := r.extractRequestParams(c)
+		fields, ok := r.selectedFields(c)
+		if !ok {
+			return
+		}
+		if r.contextDone(c) {
+			return
+		}
 		perr, response := processRequest(&params)
 		if perr != nil {
-			c.JSON(r.validator.ProcessorErr(perr))
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, processorFailure, code, e)
 			return
 		}
 
-		c.JSON(statusCode, r.convertToMap(*response))
+		r.writeResource(c, statusCode, fields, response)
+		r.publish(c, CreateEvent, r.convertToMap(response), nil)
 		return
 	})
 }
 
 func (r *APIEndpoint[Req, Resp]) HandleDelete(pathString string, processRequest func(params *RequestParams) *Err, opts ...HandleOption) {
-	config := getConfigs(opts...)
+	config := r.configs(opts...)
 	setTags(r.Path, config)
-	registerEndpoint(r.Router.BasePath()+r.Path+pathString, "DELETE", nil, nil, *config, opts...)
-	statusCode := http.StatusNoContent
-	config.StatusCode = &statusCode
-	r.Router.DELETE(r.Path+pathString, func(c *gin.Context) {
+	statusCode := config.resolveStatusCode(http.StatusNoContent)
+	registerEndpoint(r.Router.BasePath()+r.Path+pathString, "DELETE", nil, nil, *config)
+	r.handle(http.MethodDelete, pathString, config, func(c *gin.Context) {
 		params := r.extractRequestParams(c)
+		if r.contextDone(c) {
+			return
+		}
 		perr := processRequest(&params)
 		if perr != nil {
 			code, e := r.validator.ProcessorErr(perr)
-
-			c.JSON(code, e)
+			r.fail(c, processorFailure, code, e)
 			return
 		}
 
 		c.Status(statusCode)
+		resource := make(map[string]any, len(params.PathParams))
+		for key, value := range params.PathParams {
+			resource[key] = value
+		}
+		r.publish(c, DeleteEvent, resource, nil)
 		return
 	})
 }
 
+// WithContext returns the request context enriched with the params trace ID.
+// Prefer params.Context() together with TraceIDFromContext.
 func WithContext(params *RequestParams) context.Context {
-	return context.WithValue(context.Background(), "traceID", params.TraceID)
+	return ContextWithTraceID(params.Context(), params.TraceID)
+}
+
+// statusClientClosedRequest is the non standard status, borrowed from nginx,
+// recorded for requests whose client went away.
+const statusClientClosedRequest = 499
+
+// contextDone reports whether the request context has ended, in which case the
+// processor is not called. A missed deadline is answered with a timeout error,
+// a canceled request is aborted with statusClientClosedRequest and no body
+// because the client is gone.
+func (r *APIEndpoint[Req, Resp]) contextDone(c *gin.Context) bool {
+	err := c.Request.Context().Err()
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		var e *Error
+		c.AbortWithStatusJSON(e.RequestTimeout())
+		return true
+	}
+	c.AbortWithStatus(statusClientClosedRequest)
+	return true
 }
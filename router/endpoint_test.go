@@ -0,0 +1,82 @@
+package router
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	Name  *string  `json:"name" binding:"required"`
+	Price *float64 `json:"price"`
+	Id    *string  `json:"id" binding:"ignore"`
+}
+
+func newTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return gin.New()
+}
+
+func doRequest(engine *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleReplace(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/replace", engine.Group("/api"))
+	endpoint.HandleReplace("/:category/:id", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		id := params.PathParams["category"] + "-" + params.PathParams["id"]
+		item.Id = &id
+		return nil, &item
+	})
+
+	t.Run("should replace with full validation", func(t *testing.T) {
+		w := doRequest(engine, http.MethodPut, "/api/replace/books/1", `{"name":"go","price":10}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		assert.Equal(t, "books-1", body["id"])
+	})
+
+	t.Run("should reject missing required fields", func(t *testing.T) {
+		w := doRequest(engine, http.MethodPut, "/api/replace/books/1", `{"price":10}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUIRED_FIELD_ERR")
+	})
+
+	t.Run("should be registered as PUT", func(t *testing.T) {
+		endpoint, ok := Endpoints["/api/replace/{category}/{id}"]
+		assert.True(t, ok)
+		assert.Equal(t, "PUT", endpoint.Methods[0].HTTPMethod)
+		assert.Len(t, endpoint.Methods[0].Configs.PathParams, 2)
+	})
+}
+
+func TestHandleUpsert(t *testing.T) {
+	engine := newTestEngine()
+	existing := map[string]bool{"1": true}
+	endpoint := New[testItem, testItem]("/upsert", engine.Group("/api"))
+	endpoint.HandleUpsert("/:id", func(item testItem, params *RequestParams) (*Err, *testItem, bool) {
+		id := params.PathParams["id"]
+		created := !existing[id]
+		existing[id] = true
+		return nil, &item, created
+	})
+
+	w := doRequest(engine, http.MethodPut, "/api/upsert/2", `{"name":"new"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doRequest(engine, http.MethodPut, "/api/upsert/2", `{"name":"again"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
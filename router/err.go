@@ -78,3 +78,36 @@ func (e *Error) ResourceNotFound() (int, Error) {
 	}
 	return http.StatusNotFound, exp
 }
+
+// RequestTimeout returns an HTTP status code and an Error representing a
+// request whose deadline expired before it could be completed.
+func (e *Error) RequestTimeout() (int, Error) {
+	exp := Error{
+		Code:    "REQUEST_TIMEOUT_ERROR",
+		Message: "Request timeout",
+		Reason:  "The request could not be completed before its deadline",
+	}
+	return http.StatusGatewayTimeout, exp
+}
+
+// Unauthorized returns an HTTP status code and an Error representing a request
+// without valid credentials.
+func (e *Error) Unauthorized() (int, Error) {
+	exp := Error{
+		Code:    "UNAUTHORIZED_ERROR",
+		Message: "Unauthorized",
+		Reason:  "The request lacks valid authentication credentials",
+	}
+	return http.StatusUnauthorized, exp
+}
+
+// Forbidden returns an HTTP status code and an Error representing an
+// authenticated request that is not allowed to access the resource.
+func (e *Error) Forbidden() (int, Error) {
+	exp := Error{
+		Code:    "FORBIDDEN_ERROR",
+		Message: "Forbidden",
+		Reason:  "The credentials do not grant access to this resource",
+	}
+	return http.StatusForbidden, exp
+}
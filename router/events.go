@@ -0,0 +1,148 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Event is a TMF notification envelope. Event holds the resource keyed by its
+// name, e.g. {"product": {...}}. ChangedAttributes lists the top level
+// attributes touched by an update.
+type Event struct {
+	EventID           string         `json:"eventId"`
+	EventTime         time.Time      `json:"eventTime"`
+	EventType         string         `json:"eventType"`
+	Event             map[string]any `json:"event"`
+	ChangedAttributes []string       `json:"changedAttributes,omitempty"`
+}
+
+// EventBus receives the lifecycle events of the endpoints that publish to it.
+type EventBus interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// MemoryEventBus is an EventBus that keeps every event in memory and hands them
+// to its subscribers. It is meant for tests and single process setups.
+type MemoryEventBus struct {
+	mu          sync.RWMutex
+	events      []*Event
+	subscribers []func(context.Context, *Event)
+}
+
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{}
+}
+
+func (b *MemoryEventBus) Publish(ctx context.Context, event *Event) error {
+	b.mu.Lock()
+	b.events = append(b.events, event)
+	subscribers := b.subscribers
+	b.mu.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber(ctx, event)
+	}
+	return nil
+}
+
+// Subscribe registers fn to be called synchronously for every published event.
+func (b *MemoryEventBus) Subscribe(fn func(context.Context, *Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Events returns the events published so far.
+func (b *MemoryEventBus) Events() []*Event {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]*Event(nil), b.events...)
+}
+
+// Lifecycle event kinds, suffixed to the resource name to build the event type.
+const (
+	CreateEvent               = "CreateEvent"
+	AttributeValueChangeEvent = "AttributeValueChangeEvent"
+	DeleteEvent               = "DeleteEvent"
+)
+
+// PublishTo makes the endpoint publish TMF lifecycle events to bus after
+// successful creates, updates, replaces and deletes. Events are named after
+// EventResource, which defaults to the name of the response type.
+func (r *APIEndpoint[Req, Resp]) PublishTo(bus EventBus) *APIEndpoint[Req, Resp] {
+	r.events = bus
+	return r
+}
+
+func (r *APIEndpoint[Req, Resp]) eventResource() string {
+	if r.EventResource != "" {
+		return r.EventResource
+	}
+	t := reflect.TypeOf(new(Resp)).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name, _, _ := strings.Cut(t.Name(), "[")
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(first)) + name[size:]
+}
+
+// publish sends a lifecycle event for resource. Publishing happens after the
+// response is written, so failures are recorded on the gin context instead of
+// failing the request.
+func (r *APIEndpoint[Req, Resp]) publish(c *gin.Context, kind string, resource map[string]any, changed []string) {
+	if r.events == nil {
+		return
+	}
+	name := r.eventResource()
+	first, size := utf8.DecodeRuneInString(name)
+	event := &Event{
+		EventID:           newID(),
+		EventTime:         time.Now().UTC(),
+		EventType:         string(unicode.ToUpper(first)) + name[size:] + kind,
+		Event:             map[string]any{name: resource},
+		ChangedAttributes: changed,
+	}
+	if err := r.events.Publish(context.WithoutCancel(c.Request.Context()), event); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// changedAttributes returns the top level attributes an update body touches.
+func changedAttributes(mediaType string, body []byte) []string {
+	set := make(map[string]bool)
+	switch mediaType {
+	case MediaTypeJSONPatch:
+		var ops []patchOperation
+		_ = json.Unmarshal(body, &ops)
+		for _, op := range ops {
+			for _, pointer := range []*string{op.Path, op.From} {
+				if pointer == nil || op.Op == "test" {
+					continue
+				}
+				if tokens, err := parsePointer(*pointer); err == nil && len(tokens) > 0 {
+					set[tokens[0]] = true
+				}
+			}
+		}
+	default:
+		var doc map[string]any
+		_ = json.Unmarshal(body, &doc)
+		for key := range doc {
+			set[key] = true
+		}
+	}
+	changed := make([]string, 0, len(set))
+	for key := range set {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return changed
+}
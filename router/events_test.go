@@ -0,0 +1,113 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type failingBus struct{}
+
+func (failingBus) Publish(context.Context, *Event) error {
+	return errors.New("broker unavailable")
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	engine := newTestEngine()
+	bus := NewMemoryEventBus()
+	endpoint := New[testItem, testItem]("/events", engine.Group("/v1")).PublishTo(bus)
+	id := "1"
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		item.Id = &id
+		return nil, &item
+	})
+	endpoint.HandleUpdate("/:id", func(id string, item testItem, params *RequestParams) (*Err, *testItem) {
+		item.Id = &id
+		return nil, &item
+	})
+	endpoint.HandleDelete("/:id", func(params *RequestParams) *Err {
+		return nil
+	})
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		return nil, &testItem{Id: &id}
+	})
+
+	t.Run("should publish create events", func(t *testing.T) {
+		w := doRequest(engine, http.MethodPost, "/v1/events", `{"name":"pen"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		events := bus.Events()
+		assert.Len(t, events, 1)
+		assert.Equal(t, "TestItemCreateEvent", events[0].EventType)
+		assert.NotEmpty(t, events[0].EventID)
+		assert.False(t, events[0].EventTime.IsZero())
+		assert.Equal(t, map[string]any{"testItem": map[string]any{"name": "pen", "id": "1"}}, events[0].Event)
+	})
+
+	t.Run("should publish changed attributes on update", func(t *testing.T) {
+		w := doRequest(engine, http.MethodPatch, "/v1/events/1", `{"price":3,"name":"pencil"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		events := bus.Events()
+		assert.Equal(t, "TestItemAttributeValueChangeEvent", events[1].EventType)
+		assert.Equal(t, []string{"name", "price"}, events[1].ChangedAttributes)
+	})
+
+	t.Run("should publish delete events", func(t *testing.T) {
+		w := doRequest(engine, http.MethodDelete, "/v1/events/1", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		events := bus.Events()
+		assert.Equal(t, "TestItemDeleteEvent", events[2].EventType)
+		assert.Equal(t, map[string]any{"testItem": map[string]any{"id": "1"}}, events[2].Event)
+	})
+
+	t.Run("should not publish on reads or failures", func(t *testing.T) {
+		doRequest(engine, http.MethodGet, "/v1/events/1", "")
+		doRequest(engine, http.MethodPost, "/v1/events", `{"price":3}`)
+		assert.Len(t, bus.Events(), 3)
+	})
+}
+
+func TestLifecycleEventsResourceName(t *testing.T) {
+	engine := newTestEngine()
+	bus := NewMemoryEventBus()
+	var received []*Event
+	bus.Subscribe(func(_ context.Context, event *Event) {
+		received = append(received, event)
+	})
+	endpoint := New[testItem, testItem]("/orders", engine.Group("/v1")).PublishTo(bus)
+	endpoint.EventResource = "productOrder"
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		return nil, &item
+	})
+
+	doRequest(engine, http.MethodPost, "/v1/orders", `{"name":"pen"}`)
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, "ProductOrderCreateEvent", received[0].EventType)
+	assert.Contains(t, received[0].Event, "productOrder")
+}
+
+func TestLifecycleEventsPublishFailure(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/failing", engine.Group("/v1")).PublishTo(failingBus{})
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		return nil, &item
+	})
+
+	w := doRequest(engine, http.MethodPost, "/v1/failing", `{"name":"pen"}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestChangedAttributes(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, changedAttributes(MediaTypeMergePatch, []byte(`{"b":null,"a":{"c":1}}`)))
+	assert.Equal(t, []string{"a", "d"}, changedAttributes(MediaTypeJSONPatch, []byte(`[
+		{"op":"replace","path":"/a/b","value":1},
+		{"op":"move","from":"/d","path":"/a/c"},
+		{"op":"test","path":"/e","value":1}
+	]`)))
+}
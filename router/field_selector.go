@@ -1,28 +1,155 @@
 package router
 
+import (
+	"reflect"
+	"strings"
+)
+
 type fieldType map[string]interface{}
 
-func fieldSelector(fields []string, data fieldType) (fieldType, *Err) {
+// mandatoryFields are always returned by a field selection, as required by TMF630.
+var mandatoryFields = []string{"id", "href", "@type"}
+
+// fieldTree is the set of selected fields, each holding its selected sub-fields.
+// A field with no sub-fields is selected as a whole.
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(path []string) {
+	child, ok := t[path[0]]
+	if ok && len(child) == 0 {
+		// the whole field is already selected
+		return
+	}
+	if len(path) == 1 {
+		t[path[0]] = fieldTree{}
+		return
+	}
+	if !ok {
+		child = fieldTree{}
+		t[path[0]] = child
+	}
+	child.add(path[1:])
+}
+
+// parseFields parses the fields query parameter, validating each attribute
+// against the fields of t so that unknown fields are rejected before the
+// processor runs. Dotted paths address nested attributes.
+func parseFields(raw string, t reflect.Type) ([]string, *Err) {
+	raw = strings.ReplaceAll(raw, " ", "")
+	if raw == "" {
+		return nil, nil
+	}
+	fields := strings.Split(raw, ",")
+	for _, field := range fields {
+		if field == "" || !hasField(t, strings.Split(field, ".")) {
+			return nil, invalidFieldErr(field)
+		}
+	}
+	return fields, nil
+}
+
+// hasField reports whether the dotted JSON path exists in t, descending into
+// pointers, nested structs and the elements of slices. Maps and interfaces
+// accept any path.
+func hasField(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == timeType {
+			return false
+		}
+		field, ok := fieldByJSONName(t, path[0])
+		return ok && hasField(field.Type, path[1:])
+	case reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// fieldSelector projects data onto the given fields, validated by parseFields.
+// Dotted paths such as specification.adress.name select attributes of nested
+// objects and of every object in nested arrays. Fields absent from data, ex:
+// null attributes, are left out.
+func fieldSelector(fields []string, data fieldType) fieldType {
 	if len(fields) == 0 {
-		return data, nil
+		return data
 	}
-	result := make(map[string]interface{})
+	tree := fieldTree{}
 	for _, field := range fields {
+		tree.add(strings.Split(field, "."))
+	}
+	for _, field := range mandatoryFields {
+		if _, ok := data[field]; ok {
+			tree.add([]string{field})
+		}
+	}
+	return selectObject(data, tree)
+}
+
+func invalidFieldErr(field string) *Err {
+	return &Err{
+		ErrCode:    "INVALID_FIELD_ERROR",
+		ErrReason:  "The field <" + field + "> does not exist",
+		Message:    "Invalid field <" + field + ">",
+		StatusCode: 400,
+	}
+}
 
-		if value, ok := data[field]; ok {
+func selectObject(data map[string]interface{}, tree fieldTree) fieldType {
+	result := make(fieldType)
+	for field, children := range tree {
+		value, ok := data[field]
+		if !ok {
+			continue
+		}
+		if len(children) == 0 {
 			result[field] = value
 			continue
 		}
-		perr := Err{
-			ErrCode:    "INVALID_FIELD_ERROR",
-			ErrReason:  "The field <" + field + "> does not exist",
-			Message:    "Invalid field <" + field + ">",
-			StatusCode: 400,
+		if selected, ok := selectValue(value, children); ok {
+			result[field] = selected
 		}
-		return nil, &perr
+	}
+	return result
+}
+
+// selectValue projects nested objects and arrays. Other values have no
+// attributes to select and are left out.
+func selectValue(value interface{}, tree fieldTree) (interface{}, bool) {
+	if object, ok := asObject(value); ok {
+		return selectObject(object, tree), true
+	}
+	if items, ok := value.([]interface{}); ok {
+		return selectArray(items, tree), true
+	}
+	return nil, false
+}
+
+// selectArray projects every object of an array. Values that are not objects
+// are dropped.
+func selectArray(items []interface{}, tree fieldTree) []interface{} {
+	result := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if object, ok := asObject(item); ok {
+			result = append(result, selectObject(object, tree))
+		}
+	}
+	return result
+}
 
+func asObject(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case fieldType:
+		return v, true
+	case map[string]interface{}:
+		return v, true
 	}
-	return result, nil
+	return nil, false
 }
 
 func removeNilPointers(data map[string]interface{}) {
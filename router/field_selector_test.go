@@ -348,6 +348,51 @@ func TestRemoveNilPointer(t *testing.T) {
 
 }
 
+type testSelectedAddress struct {
+	Name *string `json:"name"`
+	Zip  *string `json:"zip"`
+}
+
+type testSelectedSpec struct {
+	Name   *string               `json:"name"`
+	Value  *string               `json:"value"`
+	Adress []testSelectedAddress `json:"adress"`
+}
+
+type testSelectedProduct struct {
+	ID            *string            `json:"id"`
+	Name          *string            `json:"name"`
+	Category      *testSelectedSpec  `json:"category"`
+	Specification []testSelectedSpec `json:"specification"`
+	Attributes    map[string]any     `json:"attributes"`
+}
+
+func TestParseFields(t *testing.T) {
+	productType := reflect.TypeOf(new(testSelectedProduct))
+
+	t.Run("should accept fields of the resource type", func(t *testing.T) {
+		fields, err := parseFields("name, category, specification.adress.zip, attributes.color", productType)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"name", "category", "specification.adress.zip", "attributes.color"}, fields)
+
+		fields, err = parseFields("", productType)
+		assert.Nil(t, err)
+		assert.Nil(t, fields)
+	})
+
+	t.Run("should reject unknown fields", func(t *testing.T) {
+		_, err := parseFields("specification.colour", productType)
+		assert.Equal(t, "INVALID_FIELD_ERROR", err.ErrCode)
+		assert.Equal(t, "Invalid field <specification.colour>", err.Message)
+
+		_, err = parseFields("name.first", productType)
+		assert.Equal(t, "Invalid field <name.first>", err.Message)
+
+		_, err = parseFields("name,", productType)
+		assert.Equal(t, "INVALID_FIELD_ERROR", err.ErrCode)
+	})
+}
+
 func TestFieldSelector(t *testing.T) {
 	data := fieldType{
 		"name": "John",
@@ -366,21 +411,94 @@ func TestFieldSelector(t *testing.T) {
 	}
 
 	t.Run("should select top-level fields", func(t *testing.T) {
-		fields := []string{"name"}
-		result, err := fieldSelector(fields, data)
-		if err != nil {
-			t.Fatal(err)
-		}
+		result := fieldSelector([]string{"name"}, data)
 		if result["name"] != "John" {
 			t.Errorf("Expected 'John', got '%v'", result["name"])
 		}
 	})
 
-	t.Run("should return error for non-existent fields", func(t *testing.T) {
-		fields := []string{"address.zip"}
-		_, err := fieldSelector(fields, data)
-		if err == nil {
-			t.Fatal("Expected an error, got nil")
-		}
+	t.Run("should leave out fields absent from the data", func(t *testing.T) {
+		result := fieldSelector([]string{"name", "age", "address.zip"}, data)
+		assert.Equal(t, fieldType{"name": "John", "address": fieldType{}}, result)
+	})
+}
+
+func TestNestedFieldSelector(t *testing.T) {
+	var data fieldType
+	_ = json.Unmarshal([]byte(`{
+		"id": "1",
+		"href": "/products/1",
+		"@type": "Product",
+		"name": "pen",
+		"price": 2,
+		"category": {"id": "c1", "name": "office"},
+		"specification": [
+			{"name": "color", "value": "blue", "adress": [{"name": "Main", "zip": "1"}]},
+			{"name": "size", "value": "M"}
+		]
+	}`), &data)
+
+	t.Run("should select nested fields and always return mandatory fields", func(t *testing.T) {
+		result := fieldSelector([]string{"name", "category.name"}, data)
+		assert.Equal(t, fieldType{
+			"id":       "1",
+			"href":     "/products/1",
+			"@type":    "Product",
+			"name":     "pen",
+			"category": fieldType{"name": "office"},
+		}, result)
+	})
+
+	t.Run("should select fields of every object in arrays", func(t *testing.T) {
+		result := fieldSelector([]string{"specification.name", "specification.adress.name"}, data)
+		assert.Equal(t, []interface{}{
+			fieldType{"name": "color", "adress": []interface{}{fieldType{"name": "Main"}}},
+			fieldType{"name": "size"},
+		}, result["specification"])
+	})
+
+	t.Run("should keep whole fields when a parent is selected", func(t *testing.T) {
+		result := fieldSelector([]string{"category", "category.name"}, data)
+		assert.Equal(t, map[string]interface{}{"id": "c1", "name": "office"}, result["category"])
+	})
+}
+
+func TestFieldSelectionOnWrites(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/projected", engine.Group("/v1"))
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		id := "1"
+		item.Id = &id
+		return nil, &item
+	})
+
+	w := doRequest(engine, "POST", "/v1/projected?fields=name", `{"name":"pen","price":2}`)
+	assert.Equal(t, 201, w.Code)
+	assert.JSONEq(t, `{"id":"1","name":"pen"}`, w.Body.String())
+}
+
+func TestFieldSelectionValidation(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/selected", engine.Group("/v1"))
+	var processed int
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		processed++
+		id := "1"
+		item.Id = &id
+		return nil, &item
+	})
+
+	t.Run("should reject unknown fields before the processor runs", func(t *testing.T) {
+		w := doRequest(engine, "POST", "/v1/selected?fields=bogus", `{"name":"pen"}`)
+		assert.Equal(t, 400, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_FIELD_ERROR")
+		assert.Equal(t, 0, processed)
+	})
+
+	t.Run("should accept fields whose value is null", func(t *testing.T) {
+		w := doRequest(engine, "POST", "/v1/selected?fields=price", `{"name":"pen"}`)
+		assert.Equal(t, 201, w.Code)
+		assert.JSONEq(t, `{"id":"1"}`, w.Body.String())
+		assert.Equal(t, 1, processed)
 	})
 }
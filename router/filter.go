@@ -0,0 +1,436 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterOperator is a TMF630 attribute filter comparison.
+type FilterOperator string
+
+const (
+	FilterEq    FilterOperator = "eq"
+	FilterNe    FilterOperator = "ne"
+	FilterGt    FilterOperator = "gt"
+	FilterGte   FilterOperator = "gte"
+	FilterLt    FilterOperator = "lt"
+	FilterLte   FilterOperator = "lte"
+	FilterIn    FilterOperator = "in"
+	FilterRegex FilterOperator = "regex"
+)
+
+var filterOperators = map[string]FilterOperator{
+	"eq":    FilterEq,
+	"ne":    FilterNe,
+	"gt":    FilterGt,
+	"gte":   FilterGte,
+	"lt":    FilterLt,
+	"lte":   FilterLte,
+	"in":    FilterIn,
+	"regex": FilterRegex,
+}
+
+// FilterKind is the type of the attribute a condition applies to.
+type FilterKind string
+
+const (
+	FilterString   FilterKind = "string"
+	FilterInteger  FilterKind = "integer"
+	FilterNumber   FilterKind = "number"
+	FilterBoolean  FilterKind = "boolean"
+	FilterDateTime FilterKind = "date-time"
+)
+
+// FilterCondition is a single attribute comparison such as price.gt=5. Values are
+// converted to the attribute type: string, int64, uint64, float64, bool or
+// time.Time. FilterIn conditions carry one value per alternative.
+type FilterCondition struct {
+	Field    string
+	Operator FilterOperator
+	Kind     FilterKind
+	Values   []any
+}
+
+// Value returns the first value of the condition.
+func (fc FilterCondition) Value() any {
+	if len(fc.Values) == 0 {
+		return nil
+	}
+	return fc.Values[0]
+}
+
+// Filter is a list of conditions that must all hold.
+type Filter []FilterCondition
+
+var reservedQueryParams = map[string]bool{
+	"limit":  true,
+	"offset": true,
+	"fields": true,
+	"sort":   true,
+	"cursor": true,
+}
+
+func invalidFilterErr(reason, message string) *Err {
+	return &Err{
+		ErrCode:    "INVALID_FILTER_ERROR",
+		ErrReason:  reason,
+		Message:    message,
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+// parseFilter builds a Filter out of the query attributes that are neither
+// reserved nor exempt, validating each of them against the fields of t.
+func parseFilter(query url.Values, t reflect.Type, exempt map[string]bool) (Filter, *Err) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if !reservedQueryParams[key] && !exempt[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	filter := make(Filter, 0, len(keys))
+	for _, key := range keys {
+		for _, raw := range query[key] {
+			condition, perr := parseCondition(key, raw, t)
+			if perr != nil {
+				return nil, perr
+			}
+			filter = append(filter, condition)
+		}
+	}
+	return filter, nil
+}
+
+func parseCondition(key, raw string, t reflect.Type) (FilterCondition, *Err) {
+	field, operator := key, FilterEq
+	kind, ok := resolveFieldKind(t, strings.Split(field, "."))
+	if i := strings.LastIndex(key, "."); i > 0 {
+		if op, isOp := filterOperators[key[i+1:]]; isOp && (t == nil || !ok) {
+			field, operator = key[:i], op
+			kind, ok = resolveFieldKind(t, strings.Split(field, "."))
+		}
+	}
+	if !ok {
+		return FilterCondition{}, invalidFilterErr("The attribute <"+field+"> does not exist", "Invalid filter attribute <"+field+">")
+	}
+
+	switch {
+	case operator == FilterRegex && kind != FilterString:
+		return FilterCondition{}, invalidFilterErr("The operator <regex> only applies to string attributes", "Invalid filter operator for <"+field+">")
+	case kind == FilterBoolean && operator != FilterEq && operator != FilterNe && operator != FilterIn:
+		return FilterCondition{}, invalidFilterErr("The operator <"+string(operator)+"> does not apply to boolean attributes", "Invalid filter operator for <"+field+">")
+	}
+
+	rawValues := []string{raw}
+	if operator == FilterIn {
+		rawValues = strings.Split(raw, ",")
+	}
+	condition := FilterCondition{Field: field, Operator: operator, Kind: kind}
+	for _, rawValue := range rawValues {
+		value, err := convertFilterValue(kind, operator, rawValue)
+		if err != nil {
+			return FilterCondition{}, invalidFilterErr("The value '"+rawValue+"' is not a valid "+string(kind), "Invalid filter value for <"+field+">")
+		}
+		condition.Values = append(condition.Values, value)
+	}
+	return condition, nil
+}
+
+func convertFilterValue(kind FilterKind, operator FilterOperator, raw string) (any, error) {
+	switch {
+	case operator == FilterRegex:
+		_, err := regexp.Compile(raw)
+		return raw, err
+	case kind == FilterInteger:
+		if strings.HasPrefix(raw, "-") {
+			return strconv.ParseInt(raw, 10, 64)
+		}
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return v, nil
+		}
+		return strconv.ParseUint(raw, 10, 64)
+	case kind == FilterNumber:
+		return strconv.ParseFloat(raw, 64)
+	case kind == FilterBoolean:
+		return strconv.ParseBool(raw)
+	case kind == FilterDateTime:
+		if v, err := time.Parse(time.RFC3339, raw); err == nil {
+			return v, nil
+		}
+		return time.Parse(time.DateOnly, raw)
+	}
+	return raw, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// resolveFieldKind follows a dotted JSON path through t, descending into
+// pointers, nested structs and the elements of slices. It returns the kind of
+// the leaf attribute. A nil t accepts any path as a string attribute.
+func resolveFieldKind(t reflect.Type, path []string) (FilterKind, bool) {
+	if t == nil {
+		return FilterString, true
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return leafKind(t)
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == timeType {
+			return "", false
+		}
+		field, ok := fieldByJSONName(t, path[0])
+		if !ok {
+			return "", false
+		}
+		return resolveFieldKind(field.Type, path[1:])
+	case reflect.Map, reflect.Interface:
+		return FilterString, true
+	}
+	return "", false
+}
+
+func leafKind(t reflect.Type) (FilterKind, bool) {
+	if t == timeType {
+		return FilterDateTime, true
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return FilterString, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FilterInteger, true
+	case reflect.Float32, reflect.Float64:
+		return FilterNumber, true
+	case reflect.Bool:
+		return FilterBoolean, true
+	case reflect.Interface:
+		return FilterString, true
+	}
+	return "", false
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if jsonFieldName(field) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// filterParams documents the top level attributes of t that can be filtered on.
+func filterParams(t reflect.Type) []AllowedFields {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	params := make([]AllowedFields, 0)
+	if t.Kind() != reflect.Struct {
+		return params
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		name := jsonFieldName(field)
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		kind, ok := leafKind(fieldType)
+		if !ok {
+			continue
+		}
+		operators := []string{"ne", "in"}
+		switch kind {
+		case FilterString:
+			operators = append(operators, "gt", "gte", "lt", "lte", "regex")
+		case FilterInteger, FilterNumber, FilterDateTime:
+			operators = append(operators, "gt", "gte", "lt", "lte")
+		}
+		params = append(params, AllowedFields{
+			Name:        name,
+			Description: fmt.Sprintf("filter by %s, also available as %s.{%s}", name, name, strings.Join(operators, ",")),
+			Schema:      filterSchema(kind),
+		})
+	}
+	return params
+}
+
+// filterSchema documents the values parseCondition accepts for kind.
+func filterSchema(kind FilterKind) Map {
+	if kind == FilterDateTime {
+		return Map{"type": "string", "format": "date-time"}
+	}
+	return Map{"type": string(kind)}
+}
+
+// Match reports whether data, the JSON representation of a resource, satisfies
+// every condition. Conditions on attributes inside arrays hold when any element
+// satisfies them.
+func (f Filter) Match(data map[string]any) bool {
+	for _, condition := range f {
+		if !condition.Match(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether data satisfies the condition.
+func (fc FilterCondition) Match(data map[string]any) bool {
+	values := lookupPath(data, strings.Split(fc.Field, "."))
+	for _, value := range values {
+		if fc.matchValue(value) {
+			return true
+		}
+	}
+	return fc.Operator == FilterNe && len(values) == 0
+}
+
+func (fc FilterCondition) matchValue(value any) bool {
+	switch fc.Operator {
+	case FilterRegex:
+		s, ok := value.(string)
+		pattern, _ := fc.Value().(string)
+		matched, err := regexp.MatchString(pattern, s)
+		return ok && err == nil && matched
+	case FilterIn:
+		for _, expected := range fc.Values {
+			if cmp, ok := compareFilterValues(value, expected); ok && cmp == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	cmp, ok := compareFilterValues(value, fc.Value())
+	if !ok {
+		return fc.Operator == FilterNe
+	}
+	switch fc.Operator {
+	case FilterEq:
+		return cmp == 0
+	case FilterNe:
+		return cmp != 0
+	case FilterGt:
+		return cmp > 0
+	case FilterGte:
+		return cmp >= 0
+	case FilterLt:
+		return cmp < 0
+	case FilterLte:
+		return cmp <= 0
+	}
+	return false
+}
+
+func lookupPath(data any, path []string) []any {
+	switch v := data.(type) {
+	case []any:
+		values := make([]any, 0)
+		for _, item := range v {
+			values = append(values, lookupPath(item, path)...)
+		}
+		return values
+	case map[string]any:
+		if len(path) == 0 {
+			return []any{v}
+		}
+		child, ok := v[path[0]]
+		if !ok {
+			return nil
+		}
+		return lookupPath(child, path[1:])
+	}
+	if len(path) == 0 {
+		return []any{data}
+	}
+	return nil
+}
+
+// compareFilterValues compares a JSON decoded value with a condition value and
+// returns -1, 0 or 1. It reports false when the values cannot be compared.
+func compareFilterValues(actual, expected any) (int, bool) {
+	if s, ok := expected.(string); ok {
+		switch a := actual.(type) {
+		case float64:
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				expected = f
+			}
+		case bool:
+			if b, err := strconv.ParseBool(s); err == nil {
+				expected = b
+			}
+		case string:
+			return strings.Compare(a, s), true
+		}
+	}
+
+	switch e := expected.(type) {
+	case int64:
+		expected = float64(e)
+	case uint64:
+		expected = float64(e)
+	}
+
+	switch e := expected.(type) {
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < e:
+			return -1, true
+		case a > e:
+			return 1, true
+		}
+		return 0, true
+	case bool:
+		a, ok := actual.(bool)
+		if !ok {
+			return 0, false
+		}
+		if a == e {
+			return 0, true
+		}
+		return 1, true
+	case time.Time:
+		s, ok := actual.(string)
+		if !ok {
+			return 0, false
+		}
+		a, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return 0, false
+		}
+		return a.Compare(e), true
+	}
+	return 0, false
+}
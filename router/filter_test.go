@@ -0,0 +1,132 @@
+package router
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type filterAddress struct {
+	Name *string `json:"name"`
+}
+
+type filterSpec struct {
+	Name   *string          `json:"name"`
+	Adress *[]filterAddress `json:"adress"`
+}
+
+type filterProduct struct {
+	Name          *string      `json:"name"`
+	Price         *float64     `json:"price"`
+	Stock         int          `json:"stock"`
+	Active        bool         `json:"active"`
+	CreatedAt     *time.Time   `json:"createdAt"`
+	Specification []filterSpec `json:"specification"`
+}
+
+func TestParseFilter(t *testing.T) {
+	productType := reflect.TypeOf(new(filterProduct))
+	parse := func(query string) (Filter, *Err) {
+		values, _ := url.ParseQuery(query)
+		return parseFilter(values, productType, map[string]bool{"tenant": true})
+	}
+
+	t.Run("should parse typed conditions", func(t *testing.T) {
+		filter, perr := parse("price.gt=5&name=foo&stock.in=1,2&active=true&createdAt.gte=2024-01-01T00:00:00Z&limit=10")
+		assert.Nil(t, perr)
+		assert.Equal(t, Filter{
+			{Field: "active", Operator: FilterEq, Kind: FilterBoolean, Values: []any{true}},
+			{Field: "createdAt", Operator: FilterGte, Kind: FilterDateTime, Values: []any{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			{Field: "name", Operator: FilterEq, Kind: FilterString, Values: []any{"foo"}},
+			{Field: "price", Operator: FilterGt, Kind: FilterNumber, Values: []any{5.0}},
+			{Field: "stock", Operator: FilterIn, Kind: FilterInteger, Values: []any{int64(1), int64(2)}},
+		}, filter)
+	})
+
+	t.Run("should follow dotted paths into nested objects and arrays", func(t *testing.T) {
+		filter, perr := parse("specification.adress.name.regex=^Av")
+		assert.Nil(t, perr)
+		assert.Equal(t, "specification.adress.name", filter[0].Field)
+		assert.Equal(t, FilterRegex, filter[0].Operator)
+	})
+
+	t.Run("should skip exempt params", func(t *testing.T) {
+		filter, perr := parse("tenant=acme")
+		assert.Nil(t, perr)
+		assert.Empty(t, filter)
+	})
+
+	t.Run("should reject unknown attributes", func(t *testing.T) {
+		_, perr := parse("colour=red")
+		assert.Equal(t, "INVALID_FILTER_ERROR", perr.ErrCode)
+		assert.Equal(t, http.StatusBadRequest, perr.StatusCode)
+	})
+
+	t.Run("should reject invalid values and operators", func(t *testing.T) {
+		_, perr := parse("price.gt=cheap")
+		assert.Equal(t, "INVALID_FILTER_ERROR", perr.ErrCode)
+
+		_, perr = parse("active.gt=true")
+		assert.Equal(t, "INVALID_FILTER_ERROR", perr.ErrCode)
+
+		_, perr = parse("price.regex=1")
+		assert.Equal(t, "INVALID_FILTER_ERROR", perr.ErrCode)
+	})
+}
+
+func TestFilterMatch(t *testing.T) {
+	var data map[string]any
+	_ = json.Unmarshal([]byte(`{"name":"pen","price":7,"active":true,
+		"specification":[{"adress":[{"name":"Main"}]},{"adress":[{"name":"Avenue"}]}]}`), &data)
+	values, _ := url.ParseQuery("price.gt=5&price.lte=7&active=true&specification.adress.name=Avenue&name.in=pen,pencil")
+	filter, perr := parseFilter(values, reflect.TypeOf(filterProduct{}), nil)
+	assert.Nil(t, perr)
+	assert.True(t, filter.Match(data))
+
+	values, _ = url.ParseQuery("name.ne=pen")
+	filter, _ = parseFilter(values, nil, nil)
+	assert.False(t, filter.Match(data))
+}
+
+func TestFilterParams(t *testing.T) {
+	schemas := make(map[string]Map)
+	for _, param := range filterParams(reflect.TypeOf(new(filterProduct))) {
+		schemas[param.Name] = param.Schema
+	}
+	assert.Equal(t, map[string]Map{
+		"name":      {"type": "string"},
+		"price":     {"type": "number"},
+		"stock":     {"type": "integer"},
+		"active":    {"type": "boolean"},
+		"createdAt": {"type": "string", "format": "date-time"},
+	}, schemas)
+}
+
+func TestHandleListFilter(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/filtered", engine.Group("/v1"))
+	var received Filter
+	endpoint.HandleList("", func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+		received = params.Filter
+		return []*testItem{}, nil, 0, 0
+	}, WithAllowedParams([]AllowedFields{{Name: "tenant"}}))
+
+	w := doRequest(engine, http.MethodGet, "/v1/filtered?price.gte=2&tenant=acme", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, Filter{{Field: "price", Operator: FilterGte, Kind: FilterNumber, Values: []any{2.0}}}, received)
+
+	w = doRequest(engine, http.MethodGet, "/v1/filtered?colour=red", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_FILTER_ERROR")
+
+	params := Endpoints["/v1/filtered"].Methods[0].Configs.AllowedParams
+	names := make([]string, 0)
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	assert.Equal(t, []string{"tenant", "limit", "offset", "fields", "sort", "name", "price", "id"}, names)
+}
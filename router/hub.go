@@ -0,0 +1,328 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrListenerNotFound is returned by a ListenerStore when no listener has the
+// requested ID.
+var ErrListenerNotFound = errors.New("listener not found")
+
+// Listener is a consumer registration on a TMF event hub. Query is a TMF630
+// attribute filter, e.g. eventType=ProductCreateEvent&event.product.name=pen,
+// evaluated against each event before it is delivered to Callback.
+type Listener struct {
+	ID        string    `json:"id"`
+	Callback  string    `json:"callback"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ListenerInput is the body of a hub registration request.
+type ListenerInput struct {
+	Callback *string `json:"callback" binding:"required" description:"URL the events are posted to"`
+	Query    *string `json:"query" description:"filter selecting the events to receive ex: eventType=ProductCreateEvent"`
+}
+
+// Filter parses the listener query.
+func (l *Listener) Filter() (Filter, error) {
+	values, err := url.ParseQuery(l.Query)
+	if err != nil {
+		return nil, err
+	}
+	filter, perr := parseFilter(values, nil, nil)
+	if perr != nil {
+		return nil, perr
+	}
+	return filter, nil
+}
+
+// Matches reports whether the listener query selects event, the JSON
+// representation of a notification.
+func (l *Listener) Matches(event map[string]any) bool {
+	filter, err := l.Filter()
+	return err == nil && filter.Match(event)
+}
+
+// ListenerStore persists hub registrations.
+type ListenerStore interface {
+	Save(ctx context.Context, listener *Listener) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*Listener, error)
+}
+
+// MemoryListenerStore is a ListenerStore kept in process memory.
+type MemoryListenerStore struct {
+	mu        sync.RWMutex
+	listeners map[string]*Listener
+}
+
+func NewMemoryListenerStore() *MemoryListenerStore {
+	return &MemoryListenerStore{listeners: make(map[string]*Listener)}
+}
+
+func (s *MemoryListenerStore) Save(_ context.Context, listener *Listener) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners[listener.ID] = listener
+	return nil
+}
+
+func (s *MemoryListenerStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.listeners[id]; !ok {
+		return ErrListenerNotFound
+	}
+	delete(s.listeners, id)
+	return nil
+}
+
+func (s *MemoryListenerStore) List(_ context.Context) ([]*Listener, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	listeners := make([]*Listener, 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		return listeners[i].CreatedAt.Before(listeners[j].CreatedAt)
+	})
+	return listeners, nil
+}
+
+// CallbackPolicy decides whether events may be posted to a listener callback,
+// returning an error explaining why when they may not.
+type CallbackPolicy func(ctx context.Context, callback *url.URL) error
+
+// DenyPrivateCallbacks is the default CallbackPolicy. It rejects callbacks whose
+// host is, or resolves to, a loopback, private, link-local, unspecified or
+// multicast address, so listeners cannot make the hub reach internal services
+// or cloud metadata endpoints.
+func DenyPrivateCallbacks(ctx context.Context, callback *url.URL) error {
+	host := callback.Hostname()
+	var addrs []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		addrs = []net.IP{ip}
+	} else {
+		resolved, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("the host %s cannot be resolved", host)
+		}
+		for _, addr := range resolved {
+			addrs = append(addrs, addr.IP)
+		}
+	}
+	for _, ip := range addrs {
+		if !publicIP(ip) {
+			return fmt.Errorf("the host %s is not publicly routable", host)
+		}
+	}
+	return nil
+}
+
+// publicIP reports whether ip may be reached under DenyPrivateCallbacks.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// dialPublicOnly is a net.Dialer Control refusing connections to addresses
+// DenyPrivateCallbacks rejects. It checks the address actually dialed, so a
+// host resolving elsewhere than when it was checked is refused too.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("the address %s is not publicly routable", host)
+	}
+	return nil
+}
+
+// AllowAllCallbacks is a CallbackPolicy accepting every callback, for hubs
+// whose listeners are trusted or run next to the application.
+func AllowAllCallbacks(context.Context, *url.URL) error {
+	return nil
+}
+
+// HubOption configures a Hub.
+type HubOption func(*Hub)
+
+// WithCallbackPolicy replaces DenyPrivateCallbacks as the policy checking the
+// callbacks of listeners when they register, before each delivery and on every
+// redirect. nil restores the default.
+func WithCallbackPolicy(policy CallbackPolicy) HubOption {
+	return func(h *Hub) {
+		h.policy = policy
+	}
+}
+
+// Hub manages the listeners registered through the TMF hub endpoints.
+type Hub struct {
+	store ListenerStore
+	// policy checks callbacks, DenyPrivateCallbacks when nil.
+	policy CallbackPolicy
+}
+
+// NewHub returns a hub backed by store, or by a MemoryListenerStore when store
+// is nil.
+func NewHub(store ListenerStore, opts ...HubOption) *Hub {
+	if store == nil {
+		store = NewMemoryListenerStore()
+	}
+	h := &Hub{store: store}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// checkCallback validates callback and applies the callback policy to it.
+func (h *Hub) checkCallback(ctx context.Context, callback string) *Err {
+	target, err := url.Parse(callback)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return invalidCallbackErr("The field <callback> must be an absolute http or https URL")
+	}
+	policy := h.policy
+	if policy == nil {
+		policy = DenyPrivateCallbacks
+	}
+	if err = policy(ctx, target); err != nil {
+		return invalidCallbackErr("The field <callback> is not allowed: " + err.Error())
+	}
+	return nil
+}
+
+func invalidCallbackErr(message string) *Err {
+	return &Err{
+		ErrCode:    "INVALID_CALLBACK_ERROR",
+		ErrReason:  BADREQUEST,
+		Message:    message,
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+// Register validates and stores a new listener.
+func (h *Hub) Register(ctx context.Context, callback, query string) (*Listener, *Err) {
+	if perr := h.checkCallback(ctx, callback); perr != nil {
+		return nil, perr
+	}
+	listener := &Listener{
+		ID:        newID(),
+		Callback:  callback,
+		Query:     query,
+		CreatedAt: time.Now().UTC(),
+	}
+	if _, err := listener.Filter(); err != nil {
+		var perr *Err
+		if errors.As(err, &perr) {
+			return nil, perr
+		}
+		return nil, invalidFilterErr(BADREQUEST, "The field <query> is not a valid query")
+	}
+	if err := h.store.Save(ctx, listener); err != nil {
+		return nil, hubStoreErr(err)
+	}
+	return listener, nil
+}
+
+// Unregister removes a listener.
+func (h *Hub) Unregister(ctx context.Context, id string) *Err {
+	err := h.store.Delete(ctx, id)
+	if errors.Is(err, ErrListenerNotFound) {
+		var e *Error
+		code, notFound := e.ResourceNotFound()
+		return &Err{StatusCode: code, ErrCode: notFound.Code, ErrReason: notFound.Reason, Message: notFound.Message}
+	}
+	if err != nil {
+		return hubStoreErr(err)
+	}
+	return nil
+}
+
+// Listeners returns the listeners whose query selects event.
+func (h *Hub) Listeners(ctx context.Context, event map[string]any) ([]*Listener, error) {
+	listeners, err := h.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]*Listener, 0, len(listeners))
+	for _, listener := range listeners {
+		if listener.Matches(event) {
+			matching = append(matching, listener)
+		}
+	}
+	return matching, nil
+}
+
+func hubStoreErr(err error) *Err {
+	var e *Error
+	code, internal := e.InternalServerError()
+	return &Err{StatusCode: code, ErrCode: internal.Code, ErrReason: internal.Reason, Message: err.Error()}
+}
+
+// HandleHub exposes hub under the endpoint path: POST {path}/hub registers a
+// listener and DELETE {path}/hub/:id removes it.
+func (r *APIEndpoint[Req, Resp]) HandleHub(hub *Hub, opts ...HandleOption) {
+	config := r.configs(opts...)
+	config.Tags = append(config.Tags, "hub")
+	created, noContent := http.StatusCreated, http.StatusNoContent
+	config.StatusCode = &created
+	registerEndpoint(r.Router.BasePath()+r.Path+"/hub", "POST", new(ListenerInput), new(Listener), *config)
+	config.StatusCode = &noContent
+	registerEndpoint(r.Router.BasePath()+r.Path+"/hub/:id", "DELETE", nil, nil, *config)
+
+	r.handle(http.MethodPost, "/hub", config, func(c *gin.Context) {
+		if statusCode, exception := r.validateJSONContentType(c); exception != nil {
+			r.fail(c, validationFailure, statusCode, *exception)
+			return
+		}
+		var input ListenerInput
+		if err := r.bindJSON(c.Request.Body, &input); err != nil {
+			code, e := r.validator.InputErr(err)
+			r.fail(c, validationFailure, code, e)
+			return
+		}
+		query := ""
+		if input.Query != nil {
+			query = *input.Query
+		}
+		listener, perr := hub.Register(c.Request.Context(), *input.Callback, query)
+		if perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, processorFailure, code, e)
+			return
+		}
+		c.JSON(http.StatusCreated, listener)
+	})
+
+	r.handle(http.MethodDelete, "/hub/:id", config, func(c *gin.Context) {
+		if perr := hub.Unregister(c.Request.Context(), c.Param("id")); perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, processorFailure, code, e)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// newID returns a random identifier.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
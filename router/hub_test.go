@@ -0,0 +1,91 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestHubEndpoints(t *testing.T) {
+	engine := newTestEngine()
+	hub := NewHub(nil)
+	endpoint := New[testItem, testItem]("/items", engine.Group("/hubapi"))
+	endpoint.HandleHub(hub)
+
+	w := doRequest(engine, http.MethodPost, "/hubapi/items/hub", `{"callback":"http://203.0.113.10:9000/listener","query":"eventType=ItemCreateEvent"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var listener Listener
+	_ = json.Unmarshal(w.Body.Bytes(), &listener)
+	assert.NotEmpty(t, listener.ID)
+	assert.Equal(t, "eventType=ItemCreateEvent", listener.Query)
+
+	t.Run("should match events against the listener query", func(t *testing.T) {
+		listeners, err := hub.Listeners(context.Background(), map[string]any{"eventType": "ItemCreateEvent"})
+		assert.Nil(t, err)
+		assert.Len(t, listeners, 1)
+
+		listeners, _ = hub.Listeners(context.Background(), map[string]any{"eventType": "ItemDeleteEvent"})
+		assert.Empty(t, listeners)
+	})
+
+	t.Run("should validate registrations", func(t *testing.T) {
+		w := doRequest(engine, http.MethodPost, "/hubapi/items/hub", `{"query":"eventType=ItemCreateEvent"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUIRED_FIELD_ERR")
+
+		w = doRequest(engine, http.MethodPost, "/hubapi/items/hub", `{"callback":"not a url"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_CALLBACK_ERROR")
+	})
+
+	t.Run("should reject callbacks to internal hosts", func(t *testing.T) {
+		for _, callback := range []string{
+			"http://localhost:9000/listener",
+			"http://127.0.0.1/listener",
+			"http://10.0.0.8/listener",
+			"http://192.168.1.1/listener",
+			"http://169.254.169.254/latest/meta-data",
+			"http://[::1]/listener",
+			"http://[fd00:ec2::254]/listener",
+			"http://0.0.0.0/listener",
+		} {
+			_, perr := hub.Register(context.Background(), callback, "")
+			if assert.NotNil(t, perr, callback) {
+				assert.Equal(t, "INVALID_CALLBACK_ERROR", perr.ErrCode)
+			}
+		}
+	})
+
+	t.Run("should unregister listeners", func(t *testing.T) {
+		w := doRequest(engine, http.MethodDelete, "/hubapi/items/hub/"+listener.ID, "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = doRequest(engine, http.MethodDelete, "/hubapi/items/hub/"+listener.ID, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should document the hub endpoints", func(t *testing.T) {
+		assert.Equal(t, "POST", Endpoints["/hubapi/items/hub"].Methods[0].HTTPMethod)
+		assert.Equal(t, "DELETE", Endpoints["/hubapi/items/hub/{id}"].Methods[0].HTTPMethod)
+	})
+}
+
+func TestHubCallbackPolicy(t *testing.T) {
+	hub := NewHub(nil, WithCallbackPolicy(func(_ context.Context, callback *url.URL) error {
+		if callback.Hostname() != "listener.internal" {
+			return errors.New("only listener.internal may register")
+		}
+		return nil
+	}))
+
+	_, perr := hub.Register(context.Background(), "http://listener.internal/events", "")
+	assert.Nil(t, perr)
+
+	_, perr = hub.Register(context.Background(), "http://203.0.113.10/events", "")
+	assert.Equal(t, "INVALID_CALLBACK_ERROR", perr.ErrCode)
+	assert.Contains(t, perr.Message, "only listener.internal may register")
+}
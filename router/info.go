@@ -1,7 +1,9 @@
 package router
 
 import (
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -32,12 +34,45 @@ type EndpointConfigs struct {
 	AllowedHeaders []AllowedFields
 	AllowedParams  []AllowedFields
 	PathParams     []AllowedFields
+	Consumes       []string
+	Pagination     Pagination
+	ErrorResponses []ErrorResponse
+	Security       []SecurityRequirement
+	Scopes         []string
+	Middlewares    []Middleware
+	patchLoader    func(*RequestParams) (*Err, any)
+	// params binds the parameters declared with WithParams.
+	params *paramsBinder
+	// successCodes are the statuses, besides StatusCode, a handler answers
+	// successful requests with.
+	successCodes []int
+}
+
+// ErrorResponse documents an error an endpoint may answer with. Responses that
+// share a status code are documented together, one example per error code.
+type ErrorResponse struct {
+	StatusCode  int
+	Description string
+	Example     Error
 }
 
 type AllowedFields struct {
 	Name        string
 	Description string
 	Required    bool
+	// Schema documents the type of the parameter, a string when nil.
+	Schema Map
+	// field is the params struct field the parameter is bound into.
+	field *reflect.StructField
+}
+
+// resolveStatusCode returns the status set with WithStatusCode, or defaultCode
+// when there is none, and records it for the spec.
+func (c *EndpointConfigs) resolveStatusCode(defaultCode int) int {
+	if c.StatusCode == nil {
+		c.StatusCode = &defaultCode
+	}
+	return *c.StatusCode
 }
 
 func getConfigs(opts ...HandleOption) *EndpointConfigs {
@@ -55,13 +90,12 @@ func init() {
 	Endpoints = make(map[string]*Endpoint)
 }
 
-func registerEndpoint(path, method string, request, response interface{}, config EndpointConfigs, opts ...HandleOption) {
-	opts = analyzePathParameters(path, opts...)
-	re := regexp.MustCompile(`/:(\w+)(/|$)`)
-	path = re.ReplaceAllString(path, "/{$1}$2")
-	re = regexp.MustCompile(`{(\w+)}(/|$)`)
-	_ = re.FindAllStringSubmatch(path, -1)
-	config = *getConfigs(opts...)
+func registerEndpoint(path, method string, request, response interface{}, config EndpointConfigs) {
+	checkParamCollisions(path, method, &config)
+	for _, opt := range analyzePathParameters(path) {
+		opt(&config)
+	}
+	path = openAPIPath(path)
 
 	if endpoint, ok := Endpoints[path]; ok {
 		for _, m := range endpoint.Methods {
@@ -75,6 +109,7 @@ func registerEndpoint(path, method string, request, response interface{}, config
 			Request:     request,
 			Response:    response,
 			Description: config.Descriptions,
+			StatusCode:  config.StatusCode,
 			Tags:        config.Tags,
 			Summery:     config.Name,
 			Configs:     config,
@@ -89,6 +124,7 @@ func registerEndpoint(path, method string, request, response interface{}, config
 					Request:     request,
 					Response:    response,
 					Description: config.Descriptions,
+					StatusCode:  config.StatusCode,
 					Tags:        config.Tags,
 					Summery:     config.Name,
 					Configs:     config,
@@ -98,6 +134,14 @@ func registerEndpoint(path, method string, request, response interface{}, config
 	}
 }
 
+var pathParamPattern = regexp.MustCompile(`/:(\w+)`)
+
+// openAPIPath converts a gin route such as /products/:id into its OpenAPI
+// template /products/{id}.
+func openAPIPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "/{$1}")
+}
+
 type HandleOption func(*EndpointConfigs)
 
 func WithName(name string) HandleOption {
@@ -114,7 +158,7 @@ func WithStatusCode(statusCode int) HandleOption {
 
 func WithTags(tags []string) HandleOption {
 	return func(c *EndpointConfigs) {
-		c.Tags = append(tags)
+		c.Tags = tags
 	}
 }
 
@@ -126,18 +170,39 @@ func WithDescriptions(descriptions string) HandleOption {
 
 func WithAllowedHeaders(headers []AllowedFields) HandleOption {
 	return func(c *EndpointConfigs) {
-		c.AllowedHeaders = headers
+		c.AllowedHeaders = append(c.AllowedHeaders, headers...)
 	}
 }
 
 func WithAllowedParams(params []AllowedFields) HandleOption {
 	return func(c *EndpointConfigs) {
-		c.AllowedParams = params
+		c.AllowedParams = append(c.AllowedParams, params...)
 	}
 }
+
+// WithErrorResponses documents errors returned by the processor, in addition to
+// the validation, routing and server errors documented for every endpoint.
+func WithErrorResponses(responses ...ErrorResponse) HandleOption {
+	return func(c *EndpointConfigs) {
+		c.ErrorResponses = append(c.ErrorResponses, responses...)
+	}
+}
+
+func withSuccessCodes(codes ...int) HandleOption {
+	return func(c *EndpointConfigs) {
+		c.successCodes = append(c.successCodes, codes...)
+	}
+}
+
+// withPathParams documents path parameters not already declared, ex: through
+// WithParams.
 func withPathParams(params []AllowedFields) HandleOption {
 	return func(c *EndpointConfigs) {
-		c.PathParams = params
+		for _, param := range params {
+			if !slices.ContainsFunc(c.PathParams, func(p AllowedFields) bool { return p.Name == param.Name }) {
+				c.PathParams = append(c.PathParams, param)
+			}
+		}
 	}
 }
 
@@ -0,0 +1,322 @@
+package router
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed or whose
+	// signature does not verify.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned for tokens past their exp claim.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenNotValidYet is returned for tokens before their nbf claim.
+	ErrTokenNotValidYet = errors.New("token not valid yet")
+	// ErrInvalidAudience is returned for tokens not issued for the audience.
+	ErrInvalidAudience = errors.New("invalid token audience")
+	// ErrInvalidIssuer is returned for tokens not issued by the issuer.
+	ErrInvalidIssuer = errors.New("invalid token issuer")
+)
+
+// JWTVerifier verifies HS256, RS256 and ES256 signed JSON Web Tokens against
+// locally configured keys. Keys are looked up by the kid header when the token
+// has one, otherwise every key suiting the algorithm is tried.
+type JWTVerifier struct {
+	keys     []jwtKey
+	audience string
+	issuer   string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+type jwtKey struct {
+	id  string
+	key any
+}
+
+// JWTOption configures a JWTVerifier.
+type JWTOption func(*JWTVerifier) error
+
+// WithHMACKey verifies HS256 tokens with secret.
+func WithHMACKey(kid string, secret []byte) JWTOption {
+	return func(v *JWTVerifier) error {
+		v.keys = append(v.keys, jwtKey{id: kid, key: secret})
+		return nil
+	}
+}
+
+// WithPublicKey verifies RS256 tokens with an *rsa.PublicKey or ES256 tokens
+// with a P-256 *ecdsa.PublicKey.
+func WithPublicKey(kid string, key crypto.PublicKey) JWTOption {
+	return func(v *JWTVerifier) error {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+		case *ecdsa.PublicKey:
+			if k.Curve != elliptic.P256() {
+				return errors.New("jwt: only P-256 ECDSA keys are supported")
+			}
+		default:
+			return fmt.Errorf("jwt: unsupported public key type %T", key)
+		}
+		v.keys = append(v.keys, jwtKey{id: kid, key: key})
+		return nil
+	}
+}
+
+// WithJWKSFile loads the keys of a local JSON Web Key Set file.
+func WithJWKSFile(path string) JWTOption {
+	return func(v *JWTVerifier) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return err
+		}
+		v.keys = append(v.keys, keys...)
+		return nil
+	}
+}
+
+// WithAudience requires tokens to list audience in their aud claim.
+func WithAudience(audience string) JWTOption {
+	return func(v *JWTVerifier) error {
+		v.audience = audience
+		return nil
+	}
+}
+
+// WithIssuer requires tokens to be issued by issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(v *JWTVerifier) error {
+		v.issuer = issuer
+		return nil
+	}
+}
+
+// WithLeeway tolerates clock skew when checking exp and nbf.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(v *JWTVerifier) error {
+		v.leeway = leeway
+		return nil
+	}
+}
+
+func NewJWTVerifier(opts ...JWTOption) (*JWTVerifier, error) {
+	v := &JWTVerifier{now: time.Now}
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+	}
+	if len(v.keys) == 0 {
+		return nil, errors.New("jwt: no verification keys configured")
+	}
+	return v, nil
+}
+
+// JWTScheme is a bearer SecurityScheme authenticating requests with v.
+func JWTScheme(v *JWTVerifier) *SecurityScheme {
+	return BearerScheme("JWT", v.Authenticate)
+}
+
+// Authenticate implements Authenticator.
+func (v *JWTVerifier) Authenticate(_ context.Context, token string) (*Principal, error) {
+	return v.Verify(token)
+}
+
+// Verify checks the signature and the registered claims of token and returns
+// the principal it describes. Scopes are read from the space separated scope
+// claim or the scp array claim.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidToken
+	}
+
+	claims := make(map[string]any)
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{Claims: claims, Scopes: make([]string, 0)}
+	principal.Subject, _ = claims["sub"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	} else if scp, ok := claims["scp"].([]any); ok {
+		for _, s := range scp {
+			if scope, ok := s.(string); ok {
+				principal.Scopes = append(principal.Scopes, scope)
+			}
+		}
+	}
+	return principal, nil
+}
+
+func (v *JWTVerifier) verifySignature(alg, kid, signed string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+	for _, k := range v.keys {
+		if kid != "" && k.id != "" && k.id != kid {
+			continue
+		}
+		switch key := k.key.(type) {
+		case []byte:
+			if alg != "HS256" {
+				continue
+			}
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(signed))
+			if hmac.Equal(signature, mac.Sum(nil)) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if alg != "ES256" || len(signature) != 64 {
+				continue
+			}
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			if ecdsa.Verify(key, digest[:], r, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateClaims checks the registered claims. Claims present with the wrong
+// JSON type make the token invalid rather than being skipped.
+func (v *JWTVerifier) validateClaims(claims map[string]any) error {
+	now := v.now()
+	exp, hasExp := claims["exp"]
+	nbf, hasNbf := claims["nbf"]
+	iss, hasIss := claims["iss"]
+	if _, ok := exp.(float64); hasExp && !ok {
+		return ErrInvalidToken
+	}
+	if _, ok := nbf.(float64); hasNbf && !ok {
+		return ErrInvalidToken
+	}
+	if _, ok := iss.(string); hasIss && !ok {
+		return ErrInvalidToken
+	}
+	if hasExp && now.After(time.Unix(int64(exp.(float64)), 0).Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+	if hasNbf && now.Before(time.Unix(int64(nbf.(float64)), 0).Add(-v.leeway)) {
+		return ErrTokenNotValidYet
+	}
+	if v.issuer != "" && iss != v.issuer {
+		return ErrInvalidIssuer
+	}
+	if v.audience != "" {
+		switch aud := claims["aud"].(type) {
+		case string:
+			if aud != v.audience {
+				return ErrInvalidAudience
+			}
+		case []any:
+			if !slices.Contains(aud, any(v.audience)) {
+				return ErrInvalidAudience
+			}
+		default:
+			return ErrInvalidAudience
+		}
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// parseJWKS reads the RSA, P-256 EC and symmetric keys of a JSON Web Key Set.
+func parseJWKS(data []byte) ([]jwtKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwt: invalid JWKS: %w", err)
+	}
+	decode := func(s string) *big.Int {
+		b, _ := base64.RawURLEncoding.DecodeString(s)
+		return new(big.Int).SetBytes(b)
+	}
+
+	keys := make([]jwtKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			keys = append(keys, jwtKey{id: k.Kid, key: &rsa.PublicKey{N: decode(k.N), E: int(decode(k.E).Int64())}})
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: decode(k.X), Y: decode(k.Y)}
+			if _, err := key.ECDH(); err != nil {
+				return nil, fmt.Errorf("jwt: JWKS key %s is not on its curve", k.Kid)
+			}
+			keys = append(keys, jwtKey{id: k.Kid, key: key})
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: JWKS key %s: %w", k.Kid, err)
+			}
+			keys = append(keys, jwtKey{id: k.Kid, key: secret})
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,207 @@
+package router
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		assert.Nil(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		assert.Nil(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerifier(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now := time.Now()
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":   "alice",
+			"iss":   "https://issuer.example.com",
+			"aud":   []string{"products", "orders"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "product.read product.write",
+		}
+		for key, value := range extra {
+			c[key] = value
+		}
+		return c
+	}
+
+	verifier, err := NewJWTVerifier(
+		WithHMACKey("hs", secret),
+		WithPublicKey("rs", &rsaKey.PublicKey),
+		WithPublicKey("es", &ecKey.PublicKey),
+		WithIssuer("https://issuer.example.com"),
+		WithAudience("products"),
+	)
+	assert.Nil(t, err)
+
+	t.Run("should verify supported algorithms", func(t *testing.T) {
+		for _, token := range []string{
+			signJWT(t, "HS256", "hs", secret, claims(nil)),
+			signJWT(t, "RS256", "rs", rsaKey, claims(nil)),
+			signJWT(t, "ES256", "es", ecKey, claims(nil)),
+		} {
+			principal, err := verifier.Verify(token)
+			assert.Nil(t, err)
+			assert.Equal(t, "alice", principal.Subject)
+			assert.Equal(t, []string{"product.read", "product.write"}, principal.Scopes)
+			assert.Equal(t, "https://issuer.example.com", principal.Claims["iss"])
+		}
+	})
+
+	t.Run("should read scp claims", func(t *testing.T) {
+		principal, err := verifier.Verify(signJWT(t, "HS256", "hs", secret, claims(map[string]any{"scope": nil, "scp": []string{"a", "b"}})))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a", "b"}, principal.Scopes)
+	})
+
+	t.Run("should reject invalid signatures", func(t *testing.T) {
+		otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		for _, token := range []string{
+			signJWT(t, "HS256", "hs", []byte("another secret"), claims(nil)),
+			signJWT(t, "ES256", "es", otherKey, claims(nil)),
+			signJWT(t, "HS256", "rs", secret, claims(nil)),
+			signJWT(t, "none", "", nil, claims(nil)),
+			"not-a-token",
+		} {
+			_, err := verifier.Verify(token)
+			assert.Equal(t, ErrInvalidToken, err)
+		}
+	})
+
+	t.Run("should check registered claims", func(t *testing.T) {
+		cases := map[error]map[string]any{
+			ErrTokenExpired:     {"exp": now.Add(-time.Minute).Unix()},
+			ErrTokenNotValidYet: {"nbf": now.Add(time.Minute).Unix()},
+			ErrInvalidIssuer:    {"iss": "https://other.example.com"},
+			ErrInvalidAudience:  {"aud": "billing"},
+		}
+		for expected, extra := range cases {
+			_, err := verifier.Verify(signJWT(t, "HS256", "hs", secret, claims(extra)))
+			assert.Equal(t, expected, err)
+		}
+	})
+
+	t.Run("should reject registered claims of the wrong type", func(t *testing.T) {
+		for _, extra := range []map[string]any{
+			{"exp": strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)},
+			{"nbf": strconv.FormatInt(now.Add(time.Hour).Unix(), 10)},
+			{"exp": nil},
+			{"iss": 42},
+		} {
+			_, err := verifier.Verify(signJWT(t, "HS256", "hs", secret, claims(extra)))
+			assert.Equal(t, ErrInvalidToken, err, extra)
+		}
+	})
+
+	t.Run("should tolerate clock skew", func(t *testing.T) {
+		lenient, _ := NewJWTVerifier(WithHMACKey("", secret), WithLeeway(2*time.Minute))
+		_, err := lenient.Verify(signJWT(t, "HS256", "", secret, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})))
+		assert.Nil(t, err)
+	})
+
+	t.Run("should require keys", func(t *testing.T) {
+		_, err := NewJWTVerifier()
+		assert.NotNil(t, err)
+	})
+}
+
+func TestJWKSFile(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rs","use":"sig","n":%q,"e":%q},
+		{"kty":"EC","kid":"es","crv":"P-256","x":%q,"y":%q},
+		{"kty":"RSA","kid":"enc","use":"enc","n":%q,"e":%q}
+	]}`, encode(rsaKey.N), encode(big.NewInt(int64(rsaKey.E))), encode(ecKey.X), encode(ecKey.Y), encode(rsaKey.N), encode(big.NewInt(int64(rsaKey.E))))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.Nil(t, os.WriteFile(path, []byte(jwks), 0o600))
+
+	verifier, err := NewJWTVerifier(WithJWKSFile(path))
+	assert.Nil(t, err)
+	assert.Len(t, verifier.keys, 2)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	_, err = verifier.Verify(signJWT(t, "RS256", "rs", rsaKey, map[string]any{"sub": "a", "exp": exp}))
+	assert.Nil(t, err)
+	_, err = verifier.Verify(signJWT(t, "ES256", "es", ecKey, map[string]any{"sub": "a", "exp": exp}))
+	assert.Nil(t, err)
+
+	_, err = NewJWTVerifier(WithJWKSFile(filepath.Join(t.TempDir(), "missing.json")))
+	assert.NotNil(t, err)
+}
+
+func TestJWTSecurity(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	verifier, _ := NewJWTVerifier(WithHMACKey("", secret))
+	RegisterSecurityScheme("testJWT", JWTScheme(verifier))
+
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/jwt", engine.Group("/v1"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		return nil, &testItem{Name: &params.Principal.Subject}
+	}, WithSecurity("testJWT"), WithScopes("product.read"))
+
+	request := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/jwt/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	w := request(signJWT(t, "HS256", "", secret, map[string]any{"sub": "alice", "exp": exp, "scope": "product.read"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"alice"}`, w.Body.String())
+
+	w = request(signJWT(t, "HS256", "", secret, map[string]any{"sub": "alice", "exp": exp}))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = request(signJWT(t, "HS256", "", secret, map[string]any{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()}))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
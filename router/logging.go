@@ -0,0 +1,120 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// LogOption configures RequestLogger.
+type LogOption func(*logConfig)
+
+type logConfig struct {
+	levels   [6]slog.Level
+	sampling uint64
+	skip     map[string]bool
+	fields   []func(c *gin.Context) []slog.Attr
+}
+
+// WithStatusLevel logs responses of the status class, ex: 4 for 4xx, at level.
+// 1xx to 3xx are logged at Info, 4xx at Warn and 5xx at Error by default.
+func WithStatusLevel(class int, level slog.Level) LogOption {
+	return func(c *logConfig) {
+		if class >= 1 && class <= 5 {
+			c.levels[class] = level
+		}
+	}
+}
+
+// WithSuccessSampling logs only one in every n successful requests. Failed
+// requests, 4xx and 5xx, are always logged.
+func WithSuccessSampling(n int) LogOption {
+	return func(c *logConfig) {
+		if n > 0 {
+			c.sampling = uint64(n)
+		}
+	}
+}
+
+// WithSkipPaths does not log requests to paths, ex: the health probes.
+func WithSkipPaths(paths ...string) LogOption {
+	return func(c *logConfig) {
+		for _, path := range paths {
+			c.skip[path] = true
+		}
+	}
+}
+
+// WithLogFields adds the attributes fields returns to every request entry,
+// ex: a tenant read from the request context.
+func WithLogFields(fields func(c *gin.Context) []slog.Attr) LogOption {
+	return func(c *logConfig) {
+		c.fields = append(c.fields, fields)
+	}
+}
+
+// RequestLogger returns a middleware logging one entry per request to logger.
+// Handlers find a logger carrying the trace ID of the request in
+// RequestParams.Logger and through LoggerFromContext, so their entries can be
+// correlated with the request entry.
+func RequestLogger(logger *slog.Logger, opts ...LogOption) gin.HandlerFunc {
+	config := &logConfig{
+		levels:   [6]slog.Level{slog.LevelInfo, slog.LevelInfo, slog.LevelInfo, slog.LevelInfo, slog.LevelWarn, slog.LevelError},
+		sampling: 1,
+		skip:     make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	var successes atomic.Uint64
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := c.Request.Context()
+		requestLogger := logger
+		if traceID, ok := TraceIDFromContext(ctx); ok {
+			requestLogger = logger.With(slog.String("trace_id", traceID))
+		}
+		c.Request = c.Request.WithContext(ContextWithLogger(ctx, requestLogger))
+
+		c.Next()
+
+		if config.skip[c.Request.URL.Path] {
+			return
+		}
+		status := c.Writer.Status()
+		if status < 400 && (successes.Add(1)-1)%config.sampling != 0 {
+			return
+		}
+		level := config.levels[0]
+		if class := status / 100; class >= 1 && class <= 5 {
+			level = config.levels[class]
+		}
+		if !requestLogger.Enabled(ctx, level) {
+			return
+		}
+
+		route := unmatchedRoute
+		if c.FullPath() != "" {
+			route = openAPIPath(c.FullPath())
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("size", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			attrs = append(attrs, slog.String("error", errs))
+		}
+		for _, fields := range config.fields {
+			attrs = append(attrs, fields(c)...)
+		}
+		requestLogger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
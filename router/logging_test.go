@@ -0,0 +1,116 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func logEntries(out *bytes.Buffer) []map[string]any {
+	entries := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := make(map[string]any)
+		_ = json.Unmarshal([]byte(line), &entry)
+		entries = append(entries, entry)
+	}
+	out.Reset()
+	return entries
+}
+
+func TestRequestLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	engine := newTestEngine()
+	engine.Use(Trace(), RequestLogger(logger,
+		WithStatusLevel(2, slog.LevelDebug),
+		WithSkipPaths("/health"),
+		WithLogFields(func(c *gin.Context) []slog.Attr {
+			tenant, _ := TenantFromContext(c.Request.Context())
+			return []slog.Attr{slog.String("tenant", tenant)}
+		}),
+	))
+	engine.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	endpoint := New[testItem, testItem]("/logged", engine.Group("/v1"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		params.Logger.Info("reading item", "id", params.PathParams["id"])
+		if params.PathParams["id"] == "broken" {
+			return &Err{StatusCode: http.StatusInternalServerError, ErrCode: "INTERNAL_ERROR"}, nil
+		}
+		return nil, &testItem{}
+	})
+
+	request := func(target string) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		req.Header.Set("User-Agent", "probe/1.0")
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("should log requests with the route template", func(t *testing.T) {
+		request("/v1/logged/1")
+		entries := logEntries(&out)
+		assert.Len(t, entries, 2)
+
+		assert.Equal(t, "reading item", entries[0]["msg"])
+		assert.Equal(t, "req-1", entries[0]["trace_id"])
+
+		entry := entries[1]
+		assert.Equal(t, "request", entry["msg"])
+		assert.Equal(t, "DEBUG", entry["level"])
+		assert.Equal(t, "req-1", entry["trace_id"])
+		assert.Equal(t, "/v1/logged/{id}", entry["route"])
+		assert.Equal(t, "/v1/logged/1", entry["path"])
+		assert.Equal(t, float64(http.StatusOK), entry["status"])
+		assert.Equal(t, "probe/1.0", entry["user_agent"])
+		assert.Equal(t, "", entry["tenant"])
+		assert.Greater(t, entry["size"], float64(0))
+	})
+
+	t.Run("should log failures at their status level", func(t *testing.T) {
+		request("/v1/logged/broken")
+		request("/v1/missing")
+		entries := logEntries(&out)
+		assert.Equal(t, "ERROR", entries[1]["level"])
+		assert.Equal(t, "WARN", entries[2]["level"])
+		assert.Equal(t, unmatchedRoute, entries[2]["route"])
+	})
+
+	t.Run("should skip paths", func(t *testing.T) {
+		request("/health")
+		assert.Empty(t, logEntries(&out))
+	})
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	var out bytes.Buffer
+	engine := newTestEngine()
+	engine.Use(RequestLogger(slog.New(slog.NewJSONHandler(&out, nil)), WithSuccessSampling(3)))
+	engine.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusBadRequest)
+	})
+
+	for i := 0; i < 6; i++ {
+		doRequest(engine, http.MethodGet, "/ok", "")
+		doRequest(engine, http.MethodGet, "/fail", "")
+	}
+	statuses := make(map[float64]int)
+	for _, entry := range logEntries(&out) {
+		statuses[entry["status"].(float64)]++
+	}
+	assert.Equal(t, map[float64]int{http.StatusOK: 2, http.StatusBadRequest: 6}, statuses)
+}
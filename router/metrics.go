@@ -0,0 +1,242 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failureKind tells whether a request was rejected before reaching its
+// processor or failed in it.
+type failureKind int
+
+const (
+	validationFailure failureKind = iota + 1
+	processorFailure
+)
+
+// failureKey holds the failure of the request in the gin context.
+const failureKey = "worx.failure"
+
+type failure struct {
+	kind failureKind
+	code string
+}
+
+// fail answers c with the error e, recording the failure for Metrics.
+func (r *APIEndpoint[Req, Resp]) fail(c *gin.Context, kind failureKind, statusCode int, e Error) {
+	c.Set(failureKey, failure{kind: kind, code: e.Code})
+	c.JSON(statusCode, e)
+}
+
+// DefaultBuckets are the latency histogram buckets, in seconds, used when
+// NewMetrics is given none.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// unmatchedRoute labels requests that matched no registered route.
+const unmatchedRoute = "unmatched"
+
+// Metrics collects request metrics labeled by route template, method and
+// status, and exposes them in the Prometheus text exposition format.
+type Metrics struct {
+	mu                 sync.Mutex
+	buckets            []float64
+	requests           map[[3]string]uint64
+	durations          map[[2]string]*histogram
+	inFlight           map[[2]string]int64
+	validationFailures map[[3]string]uint64
+	processorErrors    map[[4]string]uint64
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics returns empty metrics whose latency histograms use buckets, in
+// seconds, or DefaultBuckets.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:            buckets,
+		requests:           make(map[[3]string]uint64),
+		durations:          make(map[[2]string]*histogram),
+		inFlight:           make(map[[2]string]int64),
+		validationFailures: make(map[[3]string]uint64),
+		processorErrors:    make(map[[4]string]uint64),
+	}
+}
+
+// Middleware records the requests it sees. Routes are labeled with their
+// OpenAPI template, ex: /products/{id}, so the cardinality stays bounded.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return m.measure
+}
+
+func (m *Metrics) measure(c *gin.Context) {
+	route := unmatchedRoute
+	if c.FullPath() != "" {
+		route = openAPIPath(c.FullPath())
+	}
+	key := [2]string{methodLabel(c.Request.Method), route}
+
+	m.mu.Lock()
+	m.inFlight[key]++
+	m.mu.Unlock()
+	start := time.Now()
+
+	// Panics escaping the handlers are counted as 500 before being passed on
+	// to the recovery middleware.
+	defer func() {
+		status := c.Writer.Status()
+		recovered := recover()
+		if recovered != nil {
+			status = http.StatusInternalServerError
+		}
+		m.observe(c, key, status, time.Since(start).Seconds())
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	c.Next()
+}
+
+func (m *Metrics) observe(c *gin.Context, key [2]string, statusCode int, seconds float64) {
+	status := strconv.Itoa(statusCode)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[key]--
+	m.requests[[3]string{key[0], key[1], status}]++
+
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+
+	if v, ok := c.Get(failureKey); ok {
+		f := v.(failure)
+		switch f.kind {
+		case validationFailure:
+			m.validationFailures[[3]string{key[0], key[1], f.code}]++
+		case processorFailure:
+			m.processorErrors[[4]string{key[0], key[1], status, f.code}]++
+		}
+	}
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		_, _ = m.WriteTo(c.Writer)
+	}
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := new(bytes.Buffer)
+	header := func(name, kind, help string) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	header("worx_http_requests_total", "counter", "Total number of HTTP requests.")
+	for _, key := range sortedKeys(m.requests) {
+		fmt.Fprintf(out, "worx_http_requests_total{%s} %d\n", labels("method", key[0], "route", key[1], "status", key[2]), m.requests[key])
+	}
+
+	header("worx_http_request_duration_seconds", "histogram", "HTTP request latency in seconds.")
+	for _, key := range sortedKeys(m.durations) {
+		h := m.durations[key]
+		for i, bound := range m.buckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(out, "worx_http_request_duration_seconds_bucket{%s} %d\n", labels("method", key[0], "route", key[1], "le", le), h.counts[i])
+		}
+		fmt.Fprintf(out, "worx_http_request_duration_seconds_bucket{%s} %d\n", labels("method", key[0], "route", key[1], "le", "+Inf"), h.count)
+		fmt.Fprintf(out, "worx_http_request_duration_seconds_sum{%s} %s\n", labels("method", key[0], "route", key[1]), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "worx_http_request_duration_seconds_count{%s} %d\n", labels("method", key[0], "route", key[1]), h.count)
+	}
+
+	header("worx_http_requests_in_flight", "gauge", "Number of HTTP requests being served.")
+	for _, key := range sortedKeys(m.inFlight) {
+		fmt.Fprintf(out, "worx_http_requests_in_flight{%s} %d\n", labels("method", key[0], "route", key[1]), m.inFlight[key])
+	}
+
+	header("worx_validation_failures_total", "counter", "Number of requests rejected by validation, by error code.")
+	for _, key := range sortedKeys(m.validationFailures) {
+		fmt.Fprintf(out, "worx_validation_failures_total{%s} %d\n", labels("method", key[0], "route", key[1], "code", key[2]), m.validationFailures[key])
+	}
+
+	header("worx_processor_errors_total", "counter", "Number of errors returned by processors, by error code.")
+	for _, key := range sortedKeys(m.processorErrors) {
+		fmt.Fprintf(out, "worx_processor_errors_total{%s} %d\n", labels("method", key[0], "route", key[1], "status", key[2], "code", key[3]), m.processorErrors[key])
+	}
+
+	return out.WriteTo(w)
+}
+
+// otherMethod labels requests whose method is not a standard HTTP method.
+const otherMethod = "other"
+
+// methodLabel returns method when it is a standard HTTP method, otherMethod
+// otherwise, so clients cannot grow the number of series with made up methods.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
+
+// labels formats name, value pairs as Prometheus labels.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func sortedKeys[K [2]string | [3]string | [4]string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	engine := newTestEngine()
+	metrics := NewMetrics(0.1, 1)
+	engine.Use(metrics.Middleware())
+	engine.GET("/metrics", metrics.Handler())
+
+	endpoint := New[testItem, testItem]("/measured", engine.Group("/v1"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		if params.PathParams["id"] == "missing" {
+			return &Err{StatusCode: http.StatusNotFound, ErrCode: "NOT_FOUND_ERR", ErrReason: "Not Found", Message: "missing"}, nil
+		}
+		return nil, &testItem{}
+	})
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) {
+		return nil, &item
+	})
+
+	doRequest(engine, http.MethodGet, "/v1/measured/1", "")
+	doRequest(engine, http.MethodGet, "/v1/measured/2", "")
+	doRequest(engine, http.MethodGet, "/v1/measured/missing", "")
+	doRequest(engine, http.MethodPost, "/v1/measured", `{"value": 1}`)
+	doRequest(engine, http.MethodGet, "/v1/unknown", "")
+
+	var out bytes.Buffer
+	_, err := metrics.WriteTo(&out)
+	assert.Nil(t, err)
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE worx_http_requests_total counter\n")
+	assert.Contains(t, text, `worx_http_requests_total{method="GET",route="/v1/measured/{id}",status="200"} 2`+"\n")
+	assert.Contains(t, text, `worx_http_requests_total{method="GET",route="/v1/measured/{id}",status="404"} 1`+"\n")
+	assert.Contains(t, text, `worx_http_requests_total{method="GET",route="unmatched",status="404"} 1`+"\n")
+	assert.NotContains(t, text, "/v1/measured/1")
+
+	assert.Contains(t, text, "# TYPE worx_http_request_duration_seconds histogram\n")
+	assert.Contains(t, text, `worx_http_request_duration_seconds_bucket{method="GET",route="/v1/measured/{id}",le="0.1"} 3`+"\n")
+	assert.Contains(t, text, `worx_http_request_duration_seconds_bucket{method="GET",route="/v1/measured/{id}",le="+Inf"} 3`+"\n")
+	assert.Contains(t, text, `worx_http_request_duration_seconds_count{method="GET",route="/v1/measured/{id}"} 3`+"\n")
+	assert.Contains(t, text, `worx_http_requests_in_flight{method="GET",route="/v1/measured/{id}"} 0`+"\n")
+
+	assert.Contains(t, text, `worx_validation_failures_total{method="POST",route="/v1/measured",code="REQUIRED_FIELD_ERR"} 1`+"\n")
+	assert.Contains(t, text, `worx_processor_errors_total{method="GET",route="/v1/measured/{id}",status="404",code="NOT_FOUND_ERR"} 1`+"\n")
+
+	w := doRequest(engine, http.MethodGet, "/metrics", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `worx_http_requests_in_flight{method="GET",route="/metrics"} 1`)
+}
+
+func TestMetricsPanics(t *testing.T) {
+	engine := gin.New()
+	metrics := NewMetrics()
+	engine.Use(Recovery(), metrics.Middleware())
+	engine.GET("/panics", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	w := doRequest(engine, http.MethodGet, "/panics", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var out bytes.Buffer
+	_, _ = metrics.WriteTo(&out)
+	assert.Contains(t, out.String(), `worx_http_requests_total{method="GET",route="/panics",status="500"} 1`+"\n")
+	assert.Contains(t, out.String(), `worx_http_requests_in_flight{method="GET",route="/panics"} 0`+"\n")
+}
+
+func TestMetricsMethods(t *testing.T) {
+	engine := newTestEngine()
+	metrics := NewMetrics()
+	engine.Use(metrics.Middleware())
+
+	for _, method := range []string{"X0", "X1", "X2", http.MethodGet} {
+		doRequest(engine, method, "/v1/anything", "")
+	}
+
+	var out bytes.Buffer
+	_, _ = metrics.WriteTo(&out)
+	text := out.String()
+	assert.Contains(t, text, `worx_http_requests_total{method="other",route="unmatched",status="404"} 3`+"\n")
+	assert.Contains(t, text, `worx_http_requests_total{method="GET",route="unmatched",status="404"} 1`+"\n")
+	assert.NotContains(t, text, `method="X0"`)
+}
+
+func TestMetricLabels(t *testing.T) {
+	assert.Equal(t, `route="/a",code="say \"hi\"\\\n"`, labels("route", "/a", "code", "say \"hi\"\\\n"))
+}
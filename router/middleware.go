@@ -0,0 +1,78 @@
+package router
+
+import "github.com/gin-gonic/gin"
+
+// Middleware is a gin middleware together with the options documenting what it
+// expects from requests, ex: WithAllowedHeaders for the headers it reads or
+// WithErrorResponses for the errors it answers with. Docs are applied to every
+// operation the middleware guards.
+type Middleware struct {
+	Handler gin.HandlerFunc
+	Docs    []HandleOption
+}
+
+// Use runs middleware before every handler registered afterwards on the
+// endpoint.
+func (r *APIEndpoint[Req, Resp]) Use(middleware ...gin.HandlerFunc) *APIEndpoint[Req, Resp] {
+	for _, handler := range middleware {
+		r.middlewares = append(r.middlewares, Middleware{Handler: handler})
+	}
+	return r
+}
+
+// UseMiddleware is Use for documented middleware.
+func (r *APIEndpoint[Req, Resp]) UseMiddleware(middleware ...Middleware) *APIEndpoint[Req, Resp] {
+	r.middlewares = append(r.middlewares, middleware...)
+	return r
+}
+
+// WithMiddleware runs middleware before the handler of a single operation,
+// after the endpoint middleware.
+func WithMiddleware(middleware ...gin.HandlerFunc) HandleOption {
+	return func(c *EndpointConfigs) {
+		for _, handler := range middleware {
+			c.Middlewares = append(c.Middlewares, Middleware{Handler: handler})
+		}
+	}
+}
+
+// WithDocumentedMiddleware is WithMiddleware for documented middleware.
+func WithDocumentedMiddleware(middleware ...Middleware) HandleOption {
+	return func(c *EndpointConfigs) {
+		for _, m := range middleware {
+			c.Middlewares = append(c.Middlewares, m)
+			for _, doc := range m.Docs {
+				doc(c)
+			}
+		}
+	}
+}
+
+// configs builds the configuration of an operation, starting from the
+// endpoint middleware.
+func (r *APIEndpoint[Req, Resp]) configs(opts ...HandleOption) *EndpointConfigs {
+	config := getConfigs(append([]HandleOption{WithDocumentedMiddleware(r.middlewares...)}, opts...)...)
+	r.checkSecurity(config)
+	return config
+}
+
+// handle routes method and path to handler. Requests are authenticated first,
+// then go through the endpoint and operation middleware and get their
+// parameters bound.
+func (r *APIEndpoint[Req, Resp]) handle(method, path string, config *EndpointConfigs, handler gin.HandlerFunc) {
+	handlers := make([]gin.HandlerFunc, 0, len(config.Middlewares)+3)
+	if len(config.Security) > 0 {
+		schemes := make(map[string]*SecurityScheme, len(config.Security))
+		for _, requirement := range config.Security {
+			schemes[requirement.Scheme], _ = r.securityScheme(requirement.Scheme)
+		}
+		handlers = append(handlers, authenticate(securityRequirements(config), schemes))
+	}
+	for _, m := range config.Middlewares {
+		handlers = append(handlers, m.Handler)
+	}
+	if config.params != nil {
+		handlers = append(handlers, r.bindParams(config.params))
+	}
+	r.Router.Handle(method, r.Path+path, append(handlers, handler)...)
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	engine := newTestEngine()
+	var calls []string
+	trace := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			calls = append(calls, name)
+			c.Next()
+		}
+	}
+	requireTenant := Middleware{
+		Handler: func(c *gin.Context) {
+			if c.GetHeader("X-Tenant") == "" {
+				c.AbortWithStatusJSON(http.StatusBadRequest, Error{Code: "MISSING_TENANT_ERROR"})
+			}
+		},
+		Docs: []HandleOption{
+			WithAllowedHeaders([]AllowedFields{{Name: "X-Tenant", Required: true}}),
+			WithErrorResponses(ErrorResponse{StatusCode: http.StatusBadRequest, Example: Error{Code: "MISSING_TENANT_ERROR"}}),
+		},
+	}
+
+	endpoint := New[testItem, testItem]("/guarded", engine.Group("/v1")).Use(trace("endpoint"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		calls = append(calls, "handler")
+		return nil, &testItem{}
+	}, WithMiddleware(trace("operation")))
+	endpoint.UseMiddleware(requireTenant)
+	endpoint.HandleDelete("/:id", func(params *RequestParams) *Err {
+		return nil
+	})
+
+	t.Run("should run endpoint then operation middleware", func(t *testing.T) {
+		calls = nil
+		w := doRequest(engine, http.MethodGet, "/v1/guarded/1", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"endpoint", "operation", "handler"}, calls)
+	})
+
+	t.Run("should only apply to handlers registered afterwards", func(t *testing.T) {
+		w := doRequest(engine, http.MethodDelete, "/v1/guarded/1", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.True(t, strings.Contains(w.Body.String(), "MISSING_TENANT_ERROR"))
+
+		calls = nil
+		w = doRequest(engine, http.MethodGet, "/v1/guarded/1", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should document middleware metadata", func(t *testing.T) {
+		methods := Endpoints["/v1/guarded/{id}"].Methods
+		assert.Empty(t, methods[0].Configs.AllowedHeaders)
+		assert.Equal(t, []AllowedFields{{Name: "X-Tenant", Required: true}}, methods[1].Configs.AllowedHeaders)
+		assert.Len(t, methods[1].Configs.ErrorResponses, 1)
+	})
+}
@@ -0,0 +1,220 @@
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PaginationMode selects how HandleList pages through results.
+type PaginationMode string
+
+const (
+	// OffsetPagination pages with the limit and offset query parameters.
+	OffsetPagination PaginationMode = "offset"
+	// CursorPagination pages with opaque, signed continuation tokens passed in
+	// the cursor query parameter.
+	CursorPagination PaginationMode = "cursor"
+)
+
+const defaultPageSize = 30
+
+// Pagination is the paging configuration of a list endpoint. A MaxLimit of zero
+// leaves the page size unbounded.
+type Pagination struct {
+	Mode         PaginationMode
+	DefaultLimit int
+	MaxLimit     int
+	secret       []byte
+}
+
+// WithPageSize sets the page size used when the request has no limit and the
+// largest limit a request may ask for.
+func WithPageSize(defaultLimit, maxLimit int) HandleOption {
+	return func(c *EndpointConfigs) {
+		c.Pagination.DefaultLimit = defaultLimit
+		c.Pagination.MaxLimit = maxLimit
+	}
+}
+
+// WithCursorPagination switches HandleList to cursor pagination. Cursors are
+// signed with secret so clients cannot forge them; when secret is empty a random
+// one is generated, which makes cursors valid only for the running process.
+func WithCursorPagination(secret []byte) HandleOption {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return func(c *EndpointConfigs) {
+		c.Pagination.Mode = CursorPagination
+		c.Pagination.secret = secret
+	}
+}
+
+func (p Pagination) withDefaults() Pagination {
+	if p.Mode == "" {
+		p.Mode = OffsetPagination
+	}
+	if p.DefaultLimit <= 0 {
+		p.DefaultLimit = defaultPageSize
+	}
+	if p.MaxLimit > 0 && p.DefaultLimit > p.MaxLimit {
+		p.DefaultLimit = p.MaxLimit
+	}
+	return p
+}
+
+// params documents the query parameters of the pagination mode.
+func (p Pagination) params() []AllowedFields {
+	limit := AllowedFields{
+		Name:        "limit",
+		Description: fmt.Sprintf("page limit, defaults to %d", p.DefaultLimit),
+		Schema:      Map{"type": "integer", "minimum": 0},
+	}
+	if p.MaxLimit > 0 {
+		limit.Description += fmt.Sprintf(" with a maximum of %d", p.MaxLimit)
+		limit.Schema["maximum"] = p.MaxLimit
+	}
+	if p.Mode == CursorPagination {
+		return []AllowedFields{limit, {
+			Name:        "cursor",
+			Description: "opaque continuation token taken from the Link response header",
+		}}
+	}
+	return []AllowedFields{limit, {
+		Name:        "offset",
+		Description: "page number",
+		Schema:      Map{"type": "integer", "minimum": 0},
+	}}
+}
+
+type cursor struct {
+	Offset int `json:"o"`
+	Limit  int `json:"l"`
+}
+
+func (p Pagination) encodeCursor(cur cursor) string {
+	payload, _ := json.Marshal(cur)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(p.sign(encoded))
+}
+
+func (p Pagination) decodeCursor(token string) (cursor, error) {
+	var cur cursor
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return cur, errors.New("malformed cursor")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, p.sign(encoded)) {
+		return cur, errors.New("invalid cursor signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cur, err
+	}
+	if err = json.Unmarshal(payload, &cur); err != nil || cur.Offset < 0 {
+		return cur, errors.New("malformed cursor")
+	}
+	return cur, nil
+}
+
+func (p Pagination) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+func paginationErr(code, query, message string) *Err {
+	return &Err{
+		ErrCode:    code,
+		ErrReason:  BADREQUEST,
+		StatusCode: http.StatusBadRequest,
+		Message:    "the query <" + query + "> " + message,
+	}
+}
+
+// page resolves the limit and offset of a list request.
+func (p Pagination) page(c *gin.Context) (limit int, offset int, perr *Err) {
+	limit = p.DefaultLimit
+	if token := c.Query("cursor"); p.Mode == CursorPagination && token != "" {
+		cur, err := p.decodeCursor(token)
+		if err != nil {
+			return 0, 0, paginationErr("INVALID_CURSOR_ERROR", "cursor", "is not a valid continuation token")
+		}
+		offset = cur.Offset
+		if cur.Limit > 0 {
+			limit = cur.Limit
+		}
+	} else if p.Mode == OffsetPagination {
+		var err error
+		if offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil {
+			return 0, 0, paginationErr("INVALID_OFFSET_ERROR", "offset", "should be a valid integer")
+		}
+		if offset < 0 {
+			return 0, 0, paginationErr("INVALID_OFFSET_ERROR", "offset", "should not be negative")
+		}
+	}
+
+	if raw, ok := c.GetQuery("limit"); ok {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			return 0, 0, paginationErr("INVALID_LIMIT_ERROR", "limit", "should be a valid integer")
+		}
+	}
+	if p.MaxLimit > 0 && limit > p.MaxLimit {
+		return 0, 0, paginationErr("INVALID_LIMIT_ERROR", "limit", fmt.Sprintf("should not be greater than %d", p.MaxLimit))
+	}
+	return limit, offset, nil
+}
+
+// links builds the RFC 8288 Link header value for a page of results.
+func (p Pagination) links(requestURL *url.URL, limit, offset int, hasMore bool) string {
+	link := func(pageOffset int, rel string) string {
+		query := requestURL.Query()
+		query.Del("cursor")
+		query.Del("offset")
+		query.Set("limit", strconv.Itoa(limit))
+		if p.Mode == CursorPagination {
+			if pageOffset > 0 {
+				query.Set("cursor", p.encodeCursor(cursor{Offset: pageOffset, Limit: limit}))
+			}
+		} else {
+			query.Set("offset", strconv.Itoa(pageOffset))
+		}
+		target := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf(`<%s>; rel="%s"`, target.String(), rel)
+	}
+
+	links := make([]string, 0, 3)
+	if hasMore && limit > 0 {
+		links = append(links, link(offset+limit, "next"))
+	}
+	if offset > 0 {
+		links = append(links, link(max(offset-limit, 0), "prev"))
+	}
+	links = append(links, link(0, "first"))
+	return strings.Join(links, ", ")
+}
+
+// Page is one page of a list result. Total is the number of resources matching
+// the request, nil when it is unknown. HasMore reports that results exist after
+// this page; it is implied when Total exceeds the resources returned so far.
+type Page[T any] struct {
+	Items   []*T
+	Total   *int
+	HasMore bool
+}
+
+func (p *Page[T]) hasMore(offset int) bool {
+	return p.HasMore || p.Total != nil && offset+len(p.Items) < *p.Total
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func listItems(total int) func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+	return func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+		items := make([]*testItem, 0)
+		for i := offset; i < total && i < offset+limit; i++ {
+			name := "item"
+			items = append(items, &testItem{Name: &name})
+		}
+		return items, nil, total, len(items)
+	}
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>; rel="next"`)
+
+func TestOffsetPaginationLinks(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/paged", engine.Group("/v1"))
+	endpoint.HandleList("", listItems(5), WithPageSize(2, 3))
+
+	w := doRequest(engine, http.MethodGet, "/v1/paged?offset=2&name=item", "")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t,
+		`</v1/paged?limit=2&name=item&offset=4>; rel="next", </v1/paged?limit=2&name=item&offset=0>; rel="prev", </v1/paged?limit=2&name=item&offset=0>; rel="first"`,
+		w.Header().Get("Link"))
+
+	w = doRequest(engine, http.MethodGet, "/v1/paged?limit=4", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_LIMIT_ERROR")
+
+	w = doRequest(engine, http.MethodGet, "/v1/paged?offset=x", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_OFFSET_ERROR")
+
+	w = doRequest(engine, http.MethodGet, "/v1/paged?offset=-2", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_OFFSET_ERROR")
+}
+
+func TestCursorPagination(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/cursors", engine.Group("/v1"))
+	var offsets []int
+	list := listItems(5)
+	endpoint.HandleList("", func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+		offsets = append(offsets, offset)
+		return list(params, limit, offset)
+	}, WithCursorPagination([]byte("secret")), WithPageSize(2, 10))
+
+	target := "/v1/cursors"
+	for {
+		w := doRequest(engine, http.MethodGet, target, "")
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		match := nextLink.FindStringSubmatch(w.Header().Get("Link"))
+		if match == nil {
+			break
+		}
+		target = match[1]
+	}
+	assert.Equal(t, []int{0, 2, 4}, offsets)
+
+	w := doRequest(engine, http.MethodGet, "/v1/cursors?cursor=forged.token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_CURSOR_ERROR")
+
+	params := Endpoints["/v1/cursors"].Methods[0].Configs.AllowedParams
+	assert.Equal(t, "cursor", params[1].Name)
+}
+
+func TestCursorSignature(t *testing.T) {
+	pagination := Pagination{secret: []byte("one")}
+	token := pagination.encodeCursor(cursor{Offset: 10, Limit: 5})
+
+	cur, err := pagination.decodeCursor(token)
+	assert.Nil(t, err)
+	assert.Equal(t, cursor{Offset: 10, Limit: 5}, cur)
+
+	_, err = Pagination{secret: []byte("two")}.decodeCursor(token)
+	assert.NotNil(t, err)
+
+	_, err = pagination.decodeCursor(url.QueryEscape(token) + "x")
+	assert.NotNil(t, err)
+}
+
+func TestHandleListPage(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/pages", engine.Group("/v1"))
+	name, none, two := "item", 0, 2
+	endpoint.HandleListPage("", func(params *RequestParams, limit int, offset int) (*Err, *Page[testItem]) {
+		switch params.Query["case"] {
+		case "empty":
+			return nil, &Page[testItem]{Total: &none}
+		case "unknown":
+			return nil, &Page[testItem]{Items: []*testItem{{Name: &name}}, HasMore: true}
+		case "unknownLast":
+			return nil, &Page[testItem]{Items: []*testItem{{Name: &name}}}
+		}
+		return nil, &Page[testItem]{Items: []*testItem{{Name: &name}, {Name: &name}}, Total: &two}
+	}, WithAllowedParams([]AllowedFields{{Name: "case"}}))
+
+	t.Run("should answer complete collections with 200", func(t *testing.T) {
+		w := doRequest(engine, http.MethodGet, "/v1/pages", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+		assert.Equal(t, "2", w.Header().Get("X-Result-Count"))
+	})
+
+	t.Run("should always answer empty results with an array", func(t *testing.T) {
+		w := doRequest(engine, http.MethodGet, "/v1/pages?case=empty", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "[]", w.Body.String())
+		assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+		assert.Equal(t, "0", w.Header().Get("X-Result-Count"))
+	})
+
+	t.Run("should leave unknown totals out", func(t *testing.T) {
+		w := doRequest(engine, http.MethodGet, "/v1/pages?case=unknownLast", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-Total-Count"))
+		assert.NotContains(t, w.Header().Get("Link"), `rel="next"`)
+	})
+
+	t.Run("should answer partial results with 206", func(t *testing.T) {
+		w := doRequest(engine, http.MethodGet, "/v1/pages?case=unknown", "")
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Empty(t, w.Header().Get("X-Total-Count"))
+		assert.Equal(t, "1", w.Header().Get("X-Result-Count"))
+		assert.Contains(t, w.Header().Get("Link"), `rel="next"`)
+	})
+}
@@ -0,0 +1,351 @@
+package router
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paramsKey holds the parameters bound with WithParams in the gin context.
+const paramsKey = "worx.params"
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// paramsBinder binds the fields of a params struct from the request.
+type paramsBinder struct {
+	t      reflect.Type
+	fields []paramField
+}
+
+type paramField struct {
+	field    reflect.StructField
+	in       string
+	name     string
+	required bool
+	enums    []string
+	pattern  *regexp.Regexp
+	fallback string
+}
+
+// WithParams binds the query, header and path parameters of requests into a
+// P, read by the handler with Params. Fields name their parameter with a
+// query, header or path tag and may be strings, booleans, numbers, times
+// (RFC 3339 or dates), durations, encoding.TextUnmarshaler implementations,
+// pointers to them for optional parameters, or slices of them for parameters
+// given several times or as comma separated lists. The binding, enums, regex,
+// default, description and example tags are honoured and documented:
+//
+//	type ProductQuery struct {
+//		Status   []string  `query:"status" enums:"active,retired"`
+//		Since    time.Time `query:"since"`
+//		Currency string    `query:"currency" default:"EUR"`
+//		TenantID string    `header:"X-Tenant" binding:"required"`
+//		ID       string    `path:"id"`
+//	}
+//
+// Invalid parameters are answered with 400 before the handler runs.
+func WithParams[P any]() HandleOption {
+	binder := newParamsBinder(reflect.TypeOf((*P)(nil)).Elem())
+	return func(c *EndpointConfigs) {
+		c.params = binder
+		for _, f := range binder.fields {
+			param := AllowedFields{
+				Name:        f.name,
+				Description: f.field.Tag.Get("description"),
+				Required:    f.required || f.in == "path",
+				field:       &f.field,
+			}
+			switch f.in {
+			case "query":
+				c.AllowedParams = append(c.AllowedParams, param)
+			case "header":
+				c.AllowedHeaders = append(c.AllowedHeaders, param)
+			case "path":
+				c.PathParams = append(c.PathParams, param)
+			}
+		}
+	}
+}
+
+// checkParamCollisions panics when a query parameter bound with WithParams has
+// the name of another query parameter of the operation, ex: limit or sort on
+// list handlers, which the handler would read on its own and the spec would
+// list twice.
+func checkParamCollisions(path, method string, config *EndpointConfigs) {
+	for i, param := range config.AllowedParams {
+		if param.field == nil {
+			continue
+		}
+		for j, other := range config.AllowedParams {
+			if i != j && other.Name == param.Name {
+				panic(fmt.Sprintf("router: query parameter %s bound with WithParams on %s %s is reserved by the handler", param.Name, method, path))
+			}
+		}
+	}
+}
+
+// Params returns the parameters bound with WithParams[P], or nil when the
+// endpoint binds none.
+func Params[P any](params *RequestParams) *P {
+	p, _ := params.bound.(*P)
+	return p
+}
+
+// newParamsBinder inspects the tagged fields of t. Unsupported field types are
+// programming errors and panic when the endpoint is declared.
+func newParamsBinder(t reflect.Type) *paramsBinder {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("router: params type %s is not a struct", t))
+	}
+	binder := &paramsBinder{t: t}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || promotedThroughPointer(t, field.Index) {
+			continue
+		}
+		for _, in := range []string{"query", "header", "path"} {
+			name := field.Tag.Get(in)
+			if name == "" {
+				continue
+			}
+			if !bindable(field.Type) {
+				panic(fmt.Sprintf("router: unsupported type %s for %s parameter %s", field.Type, in, name))
+			}
+			f := paramField{
+				field:    field,
+				in:       in,
+				name:     name,
+				required: hasBinding(field, "required"),
+				fallback: field.Tag.Get("default"),
+			}
+			if enums := field.Tag.Get("enums"); enums != "" {
+				f.enums = strings.Split(enums, ",")
+			}
+			if regex := field.Tag.Get("regex"); regex != "" {
+				f.pattern = regexp.MustCompile(regex)
+			}
+			binder.fields = append(binder.fields, f)
+		}
+	}
+	return binder
+}
+
+// bindable reports whether parameters can be converted into a t.
+func bindable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) || t == timeType || t == durationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && bindable(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// bind reads the parameters of c into a new params struct.
+func (b *paramsBinder) bind(c *gin.Context) (any, *Err) {
+	p := reflect.New(b.t)
+	for _, f := range b.fields {
+		values := f.values(c)
+		if len(values) == 0 && f.fallback != "" {
+			values = []string{f.fallback}
+		}
+		if len(values) == 0 {
+			if f.required || f.in == "path" {
+				return nil, paramErr("REQUIRED_FIELD_ERR", fmt.Sprintf("The %s parameter %s is required", f.in, f.name))
+			}
+			continue
+		}
+		for _, value := range values {
+			if f.enums != nil && !slices.Contains(f.enums, value) {
+				return nil, paramErr("INVALID_ENUM_ERR", fmt.Sprintf("The %s parameter %s should be one of %s", f.in, f.name, strings.Join(f.enums, ", ")))
+			}
+			if f.pattern != nil && !f.pattern.MatchString(value) {
+				return nil, paramErr("INVALID_PATTERN_ERR", fmt.Sprintf("The %s parameter %s should match %s", f.in, f.name, f.pattern))
+			}
+		}
+		if err := setParam(p.Elem().FieldByIndex(f.field.Index), values); err != nil {
+			code := "INVALID_TYPE_ERR"
+			if t := indirect(f.field.Type); t == timeType || t.Kind() == reflect.Slice && indirect(t.Elem()) == timeType {
+				code = "INVALID_TIME_ERR"
+			}
+			return nil, paramErr(code, fmt.Sprintf("The %s parameter %s is invalid: %v", f.in, f.name, err))
+		}
+	}
+	return p.Interface(), nil
+}
+
+// values returns the raw values of the parameter. Repeated parameters and comma
+// separated lists both fill slices.
+func (f paramField) values(c *gin.Context) []string {
+	var raw []string
+	switch f.in {
+	case "query":
+		raw = c.QueryArray(f.name)
+	case "header":
+		raw = c.Request.Header.Values(f.name)
+	case "path":
+		if value := c.Param(f.name); value != "" {
+			raw = []string{value}
+		}
+	}
+	if indirect(f.field.Type).Kind() != reflect.Slice || reflect.PointerTo(indirect(f.field.Type)).Implements(textUnmarshalerType) {
+		if len(raw) == 0 {
+			return nil
+		}
+		return raw[:1]
+	}
+	values := make([]string, 0, len(raw))
+	for _, value := range raw {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// setParam converts values into v, allocating pointers and slices.
+func setParam(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setParam(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	value := values[0]
+	switch {
+	case v.Type() == timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, value)
+		}
+		if err != nil {
+			return fmt.Errorf("%q is not a RFC 3339 date-time or date", value)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Addr().Type().Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a positive integer", value)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
+
+// promotedThroughPointer reports whether the field at index is promoted from an
+// embedded pointer, which a zero params struct cannot hold values for.
+func promotedThroughPointer(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		t = t.Field(i).Type
+		if t.Kind() == reflect.Ptr {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultParam returns the value of the default tag of field, converted to the
+// field type so it is documented as such.
+func defaultParam(field reflect.StructField) (any, bool) {
+	fallback := field.Tag.Get("default")
+	if fallback == "" {
+		return nil, false
+	}
+	v := reflect.New(indirect(field.Type)).Elem()
+	if setParam(v, []string{fallback}) != nil {
+		return fallback, true
+	}
+	switch v.Type() {
+	case timeType, durationType:
+		return fallback, true
+	}
+	return v.Interface(), true
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func paramErr(code, message string) *Err {
+	return &Err{StatusCode: http.StatusBadRequest, ErrCode: code, ErrReason: BADREQUEST, Message: message}
+}
+
+// bindParams returns a middleware binding the parameters of requests, answering
+// invalid ones with 400.
+func (r *APIEndpoint[Req, Resp]) bindParams(binder *paramsBinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, perr := binder.bind(c)
+		if perr != nil {
+			code, e := r.validator.ProcessorErr(perr)
+			r.fail(c, validationFailure, code, e)
+			c.Abort()
+			return
+		}
+		c.Set(paramsKey, p)
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type tenantHeader struct {
+	Tenant string `header:"X-Tenant" binding:"required" description:"tenant the request acts for"`
+}
+
+type itemParams struct {
+	tenantHeader
+	ID      int           `path:"id"`
+	Status  []string      `query:"status" enums:"active,retired"`
+	Since   *time.Time    `query:"since"`
+	Limit   int           `query:"limit" default:"20"`
+	Verbose bool          `query:"verbose"`
+	Timeout time.Duration `query:"timeout"`
+	Code    string        `query:"code" regex:"^[A-Z]{3}$"`
+}
+
+func TestParamsReservedNames(t *testing.T) {
+	type pageParams struct {
+		Limit int `query:"limit" default:"20"`
+	}
+	type selectParams struct {
+		Fields string `query:"fields"`
+	}
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/reserved", engine.Group("/v1"))
+
+	assert.PanicsWithValue(t, "router: query parameter limit bound with WithParams on GET /v1/reserved is reserved by the handler", func() {
+		endpoint.HandleList("", func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+			return nil, nil, 0, 0
+		}, WithParams[pageParams]())
+	})
+	assert.Panics(t, func() {
+		endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+			return nil, &testItem{}
+		}, WithParams[selectParams]())
+	})
+}
+
+func TestParams(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/typed", engine.Group("/v1"))
+	var bound *itemParams
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		bound = Params[itemParams](params)
+		return nil, &testItem{}
+	}, WithParams[itemParams]())
+	endpoint.HandleDelete("/:id", func(params *RequestParams) *Err {
+		assert.Nil(t, Params[itemParams](params))
+		return nil
+	})
+
+	request := func(target string, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should bind and convert parameters", func(t *testing.T) {
+		w := request("/v1/typed/7?status=active&status=retired,active&since=2024-05-01&verbose=true&timeout=1m30s&code=ABC", "acme")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "acme", bound.Tenant)
+		assert.Equal(t, 7, bound.ID)
+		assert.Equal(t, []string{"active", "retired", "active"}, bound.Status)
+		assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), *bound.Since)
+		assert.Equal(t, 20, bound.Limit)
+		assert.True(t, bound.Verbose)
+		assert.Equal(t, 90*time.Second, bound.Timeout)
+		assert.Equal(t, "ABC", bound.Code)
+
+		request("/v1/typed/7?limit=5&since=2024-05-01T10:00:00Z", "acme")
+		assert.Equal(t, 5, bound.Limit)
+		assert.Equal(t, 10, bound.Since.Hour())
+	})
+
+	t.Run("should leave optional parameters unset", func(t *testing.T) {
+		request("/v1/typed/7", "acme")
+		assert.Nil(t, bound.Since)
+		assert.Nil(t, bound.Status)
+	})
+
+	t.Run("should reject invalid parameters", func(t *testing.T) {
+		cases := map[string]struct {
+			target string
+			tenant string
+		}{
+			"REQUIRED_FIELD_ERR":  {"/v1/typed/7", ""},
+			"INVALID_TYPE_ERR":    {"/v1/typed/seven", "acme"},
+			"INVALID_ENUM_ERR":    {"/v1/typed/7?status=active,lost", "acme"},
+			"INVALID_TIME_ERR":    {"/v1/typed/7?since=yesterday", "acme"},
+			"INVALID_PATTERN_ERR": {"/v1/typed/7?code=abc", "acme"},
+		}
+		for code, c := range cases {
+			w := request(c.target, c.tenant)
+			assert.Equal(t, http.StatusBadRequest, w.Code, code)
+			var e Error
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &e))
+			assert.Equal(t, code, e.Code)
+			assert.Equal(t, BADREQUEST, e.Reason)
+		}
+	})
+
+	t.Run("should not bind endpoints without params", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/typed/7", nil)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("should document typed parameters", func(t *testing.T) {
+		spec, err := NewOpenAPI("test", "1.0", "").
+			SetEndpoints(map[string]*Endpoint{"/v1/typed/{id}": Endpoints["/v1/typed/{id}"]}).
+			Build()
+		assert.Nil(t, err)
+
+		parameters := make(map[string]Map)
+		for _, parameter := range spec["paths"].(Map)["/v1/typed/{id}"].(Map)["get"].(Map)["parameters"].([]Map) {
+			parameters[parameter["in"].(string)+":"+parameter["name"].(string)] = parameter
+		}
+		assert.Equal(t, Map{"in": "header", "name": "X-Tenant", "description": "tenant the request acts for", "required": true, "schema": Map{"type": "string"}}, parameters["header:X-Tenant"])
+		assert.Equal(t, Map{"type": "integer", "format": "int64"}, parameters["path:id"]["schema"])
+		assert.Equal(t, true, parameters["path:id"]["required"])
+		assert.Equal(t, Map{"type": "array", "items": Map{"type": "string", "enum": []string{"active", "retired"}}}, parameters["query:status"]["schema"])
+		assert.Equal(t, Map{"type": "string", "format": "date-time"}, parameters["query:since"]["schema"])
+		assert.Equal(t, Map{"type": "integer", "format": "int64", "default": 20}, parameters["query:limit"]["schema"])
+		assert.Equal(t, Map{"type": "boolean"}, parameters["query:verbose"]["schema"])
+		assert.Equal(t, Map{"type": "string", "format": "duration"}, parameters["query:timeout"]["schema"])
+		assert.Equal(t, Map{"type": "string", "pattern": "^[A-Z]{3}$"}, parameters["query:code"]["schema"])
+		assert.Equal(t, Map{"type": "string"}, parameters["query:fields"]["schema"])
+
+		assert.Len(t, Endpoints["/v1/typed/{id}"].Methods[0].Configs.PathParams, 1)
+	})
+
+	t.Run("should reject unsupported types", func(t *testing.T) {
+		assert.Panics(t, func() {
+			WithParams[struct {
+				Filter map[string]string `query:"filter"`
+			}]()
+		})
+	})
+}
@@ -0,0 +1,355 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types accepted by HandleUpdate.
+const (
+	MediaTypeJSON       = "application/json"
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// WithPatchLoader enables JSON Merge Patch (RFC 7386) and JSON Patch (RFC 6902)
+// bodies on HandleUpdate. The loader returns the current state of the resource,
+// the patch is applied to it and the result is bound into the request type with
+// full validation before reaching the processor.
+func WithPatchLoader[Resp any](loader func(params *RequestParams) (*Err, *Resp)) HandleOption {
+	return func(c *EndpointConfigs) {
+		c.patchLoader = func(params *RequestParams) (*Err, any) {
+			perr, resp := loader(params)
+			if perr != nil {
+				return perr, nil
+			}
+			return nil, resp
+		}
+	}
+}
+
+type patchOperation struct {
+	Op    string  `json:"op"`
+	Path  *string `json:"path"`
+	From  *string `json:"from"`
+	Value any     `json:"value"`
+}
+
+func invalidPatchErr(message string) *Err {
+	return &Err{
+		ErrCode:    "INVALID_PATCH_ERROR",
+		ErrReason:  BADREQUEST,
+		Message:    message,
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+func patchConflictErr(message string) *Err {
+	return &Err{
+		ErrCode:    "PATCH_CONFLICT_ERROR",
+		ErrReason:  "The patch cannot be applied to the current resource",
+		Message:    message,
+		StatusCode: http.StatusConflict,
+	}
+}
+
+// applyPatch applies a patch document of the given media type to doc.
+func applyPatch(mediaType string, doc any, patch []byte) (any, *Err) {
+	switch mediaType {
+	case MediaTypeMergePatch:
+		var patchDoc any
+		if err := json.Unmarshal(patch, &patchDoc); err != nil {
+			return nil, invalidPatchErr("The merge patch document is not a valid JSON")
+		}
+		return mergePatch(doc, patchDoc), nil
+	case MediaTypeJSONPatch:
+		var ops []patchOperation
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, invalidPatchErr("The JSON patch document must be an array of operations")
+		}
+		return applyJSONPatch(doc, ops)
+	}
+	return nil, invalidPatchErr("Unsupported patch media type " + mediaType)
+}
+
+// mergePatch implements the MergePatch algorithm of RFC 7386.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// applyJSONPatch applies the operations of RFC 6902 in order. The document is
+// left untouched when an operation fails.
+func applyJSONPatch(doc any, ops []patchOperation) (any, *Err) {
+	doc = deepCopy(doc)
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, invalidPatchErr(fmt.Sprintf("Operation %d is missing <path>", i))
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, invalidPatchErr(err.Error())
+		}
+		var from []string
+		if op.Op == "move" || op.Op == "copy" {
+			if op.From == nil {
+				return nil, invalidPatchErr(fmt.Sprintf("Operation %d is missing <from>", i))
+			}
+			if from, err = parsePointer(*op.From); err != nil {
+				return nil, invalidPatchErr(err.Error())
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			doc, err = pointerAdd(doc, path, deepCopy(op.Value))
+		case "remove":
+			doc, err = pointerRemove(doc, path)
+		case "replace":
+			if _, err = pointerGet(doc, path); err == nil {
+				doc, err = pointerReplace(doc, path, deepCopy(op.Value))
+			}
+		case "move":
+			var value any
+			if value, err = pointerGet(doc, from); err == nil {
+				if doc, err = pointerRemove(doc, from); err == nil {
+					doc, err = pointerAdd(doc, path, value)
+				}
+			}
+		case "copy":
+			var value any
+			if value, err = pointerGet(doc, from); err == nil {
+				doc, err = pointerAdd(doc, path, deepCopy(value))
+			}
+		case "test":
+			var value any
+			if value, err = pointerGet(doc, path); err == nil && !reflect.DeepEqual(value, deepCopy(op.Value)) {
+				err = fmt.Errorf("test failed for path <%s>", *op.Path)
+			}
+		default:
+			return nil, invalidPatchErr(fmt.Sprintf("Operation %d has an invalid op <%s>", i, op.Op))
+		}
+		if err != nil {
+			return nil, patchConflictErr(err.Error())
+		}
+	}
+	return doc, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer <%s>", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens, nil
+}
+
+func pointerGet(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("path member <%s> does not exist", token)
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("path member <%s> does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+func pointerAdd(doc any, tokens []string, value any) (any, error) {
+	return updateParent(doc, tokens, value, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			if key == "-" {
+				return append(container, value), nil
+			}
+			index, err := arrayIndex(key, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("cannot add member <%s>", key)
+	})
+}
+
+func pointerRemove(doc any, tokens []string) (any, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	return updateParent(doc, tokens, nil, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			if _, ok := container[key]; !ok {
+				return nil, fmt.Errorf("path member <%s> does not exist", key)
+			}
+			delete(container, key)
+			return container, nil
+		case []any:
+			index, err := arrayIndex(key, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("path member <%s> does not exist", key)
+	})
+}
+
+func pointerReplace(doc any, tokens []string, value any) (any, error) {
+	return updateParent(doc, tokens, value, func(parent any, key string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			index, err := arrayIndex(key, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("path member <%s> does not exist", key)
+	})
+}
+
+// updateParent walks to the container addressed by all but the last token,
+// applies fn to it and writes the possibly reallocated container back. An empty
+// pointer addresses the whole document, which is replaced by root.
+func updateParent(doc any, tokens []string, root any, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(tokens) == 0 {
+		return root, nil
+	}
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+	child, err := pointerGet(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, tokens[1:], root, fn)
+	if err != nil {
+		return nil, err
+	}
+	return pointerReplace(doc, tokens[:1], child)
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index <%s>", token)
+	}
+	return index, nil
+}
+
+func deepCopy(value any) any {
+	data, _ := json.Marshal(value)
+	var out any
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+// projectOnto cuts a resource document down to the fields clients may send in
+// the request type t, recursively, so it can be bound back into t. Fields of
+// the response type only, ex: href, and server managed fields, tagged
+// binding:"ignore", are removed.
+func projectOnto(t reflect.Type, data map[string]any) {
+	fields := requestFields(indirect(t))
+	if fields == nil {
+		return
+	}
+	for name, value := range data {
+		field, ok := fields[name]
+		if !ok {
+			delete(data, name)
+			continue
+		}
+		fieldType := indirect(field.Type)
+		switch value := value.(type) {
+		case map[string]any:
+			if fieldType.Kind() == reflect.Struct {
+				projectOnto(fieldType, value)
+			}
+		case []any:
+			if fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+				continue
+			}
+			for _, item := range value {
+				if m, ok := item.(map[string]any); ok {
+					projectOnto(fieldType.Elem(), m)
+				}
+			}
+		}
+	}
+}
+
+// requestFields returns the fields of the struct t clients may send, by JSON
+// name, flattening embedded structs. It returns nil for other types.
+func requestFields(t reflect.Type) map[string]reflect.StructField {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || hasBinding(field, "ignore") {
+			continue
+		}
+		if embedded := indirect(field.Type); field.Anonymous && strings.Split(tag, ",")[0] == "" && embedded.Kind() == reflect.Struct {
+			for name, f := range requestFields(embedded) {
+				if _, ok := fields[name]; !ok {
+					fields[name] = f
+				}
+			}
+			continue
+		}
+		if field.IsExported() {
+			fields[jsonFieldName(field)] = field
+		}
+	}
+	return fields
+}
@@ -0,0 +1,196 @@
+package router
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]any{"a": "b", "c": map[string]any{"d": "e", "f": "g"}}
+	var patch any
+	_ = json.Unmarshal([]byte(`{"a":"z","c":{"f":null}}`), &patch)
+
+	result := mergePatch(target, patch)
+
+	assert.Equal(t, map[string]any{"a": "z", "c": map[string]any{"d": "e"}}, result)
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	apply := func(doc, patch string) (any, *Err) {
+		var d any
+		_ = json.Unmarshal([]byte(doc), &d)
+		return applyPatch(MediaTypeJSONPatch, d, []byte(patch))
+	}
+	decode := func(doc string) any {
+		var d any
+		_ = json.Unmarshal([]byte(doc), &d)
+		return d
+	}
+
+	t.Run("should apply operations in order", func(t *testing.T) {
+		result, perr := apply(`{"foo":["bar","baz"],"a":{"b":1}}`, `[
+			{"op":"add","path":"/foo/1","value":"qux"},
+			{"op":"remove","path":"/foo/0"},
+			{"op":"replace","path":"/a/b","value":2},
+			{"op":"copy","from":"/a","path":"/c"},
+			{"op":"move","from":"/c/b","path":"/d"},
+			{"op":"add","path":"/foo/-","value":"end"},
+			{"op":"test","path":"/d","value":2}
+		]`)
+		assert.Nil(t, perr)
+		assert.Equal(t, decode(`{"foo":["qux","baz","end"],"a":{"b":2},"c":{},"d":2}`), result)
+	})
+
+	t.Run("should unescape pointers", func(t *testing.T) {
+		result, perr := apply(`{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`)
+		assert.Nil(t, perr)
+		assert.Equal(t, decode(`{"a/b":3}`), result)
+	})
+
+	t.Run("should fail on a failed test", func(t *testing.T) {
+		_, perr := apply(`{"a":1}`, `[{"op":"test","path":"/a","value":2}]`)
+		assert.Equal(t, "PATCH_CONFLICT_ERROR", perr.ErrCode)
+	})
+
+	t.Run("should fail on missing members", func(t *testing.T) {
+		_, perr := apply(`{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`)
+		assert.Equal(t, http.StatusConflict, perr.StatusCode)
+	})
+
+	t.Run("should reject invalid documents", func(t *testing.T) {
+		_, perr := apply(`{"a":1}`, `{"op":"add"}`)
+		assert.Equal(t, "INVALID_PATCH_ERROR", perr.ErrCode)
+
+		_, perr = apply(`{"a":1}`, `[{"op":"jump","path":"/a"}]`)
+		assert.Equal(t, "INVALID_PATCH_ERROR", perr.ErrCode)
+	})
+}
+
+func TestHandleUpdatePatchMediaTypes(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/patch", engine.Group("/v1"))
+	name, price, id := "pen", 2.0, "1"
+	loader := WithPatchLoader(func(params *RequestParams) (*Err, *testItem) {
+		return nil, &testItem{Name: &name, Price: &price, Id: &id}
+	})
+	endpoint.HandleUpdate("/:id", func(id string, item testItem, params *RequestParams) (*Err, *testItem) {
+		item.Id = &id
+		return nil, &item
+	}, loader)
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/patch/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should apply merge patches to the loaded resource", func(t *testing.T) {
+		w := patch(MediaTypeMergePatch, `{"price":3}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name":"pen","price":3,"id":"1"}`, w.Body.String())
+	})
+
+	t.Run("should apply json patches to the loaded resource", func(t *testing.T) {
+		w := patch(MediaTypeJSONPatch, `[{"op":"replace","path":"/name","value":"pencil"}]`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name":"pencil","price":2,"id":"1"}`, w.Body.String())
+	})
+
+	t.Run("should validate the patched resource in full", func(t *testing.T) {
+		w := patch(MediaTypeMergePatch, `{"name":null}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUIRED_FIELD_ERR")
+	})
+
+	t.Run("should reject changes to ignored fields", func(t *testing.T) {
+		w := patch(MediaTypeMergePatch, `{"id":"2"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_FIELD_ERR")
+	})
+
+	t.Run("should keep partial json updates", func(t *testing.T) {
+		w := patch(MediaTypeJSON, `{"price":5}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"price":5,"id":"1"}`, w.Body.String())
+	})
+
+	t.Run("should reject other media types", func(t *testing.T) {
+		w := patch("text/plain", `price=5`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("should document the accepted media types", func(t *testing.T) {
+		configs := Endpoints["/v1/patch/{id}"].Methods[0].Configs
+		assert.Equal(t, []string{MediaTypeJSON, MediaTypeMergePatch, MediaTypeJSONPatch}, configs.Consumes)
+	})
+}
+
+type testPriceInput struct {
+	Amount *float64 `json:"amount" binding:"required"`
+}
+
+type testPrice struct {
+	Amount   *float64 `json:"amount"`
+	Currency *string  `json:"currency"`
+}
+
+type testProductCreate struct {
+	Name  *string           `json:"name" binding:"required"`
+	Price *testPriceInput   `json:"price"`
+	Tags  []*testPriceInput `json:"tags"`
+}
+
+type testProduct struct {
+	ID    *string      `json:"id"`
+	Href  *string      `json:"href"`
+	Type  *string      `json:"@type"`
+	Name  *string      `json:"name"`
+	Price *testPrice   `json:"price"`
+	Tags  []*testPrice `json:"tags"`
+}
+
+func TestHandleUpdatePatchResponseFields(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testProductCreate, testProduct]("/products", engine.Group("/v1"))
+	id, href, kind, name, amount, currency := "1", "/v1/products/1", "Product", "pen", 2.0, "EUR"
+	loader := WithPatchLoader(func(params *RequestParams) (*Err, *testProduct) {
+		return nil, &testProduct{
+			ID: &id, Href: &href, Type: &kind, Name: &name,
+			Price: &testPrice{Amount: &amount, Currency: &currency},
+			Tags:  []*testPrice{{Amount: &amount, Currency: &currency}},
+		}
+	})
+	endpoint.HandleUpdate("/:id", func(id string, input testProductCreate, params *RequestParams) (*Err, *testProduct) {
+		return nil, &testProduct{ID: &id, Name: input.Name, Price: &testPrice{Amount: input.Price.Amount}}
+	}, loader)
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/products/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should drop response only fields of the loaded resource", func(t *testing.T) {
+		w := patch(MediaTypeMergePatch, `{"price":{"amount":3}}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id":"1","name":"pen","price":{"amount":3}}`, w.Body.String())
+
+		w = patch(MediaTypeJSONPatch, `[{"op":"replace","path":"/name","value":"pencil"}]`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id":"1","name":"pencil","price":{"amount":2}}`, w.Body.String())
+	})
+
+	t.Run("should reject patches to response only fields", func(t *testing.T) {
+		w := patch(MediaTypeMergePatch, `{"href":"/elsewhere"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_FIELD_ERR")
+	})
+}
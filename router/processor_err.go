@@ -14,3 +14,7 @@ func (e *Err) Error() string {
 	e.err = errors.New(e.Message)
 	return e.err.Error()
 }
+
+func (e *Err) toError() Error {
+	return Error{Code: e.ErrCode, Reason: e.ErrReason, Message: e.Message}
+}
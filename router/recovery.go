@@ -0,0 +1,62 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+)
+
+// PanicHook is told about panics recovered while serving c, ex: to report them
+// to an error tracker.
+type PanicHook func(c *gin.Context, recovered any, stack []byte)
+
+// Recovery returns a middleware recovering from panics in the handlers after
+// it. The panic and its stack trace are logged with the trace ID of the
+// request and handed to the hooks, while the client only receives the generic
+// InternalServerError body. Panics caused by clients going away are not
+// answered.
+func Recovery(hooks ...PanicHook) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			stack := debug.Stack()
+
+			logger, ok := LoggerFromContext(c.Request.Context())
+			if !ok {
+				logger = slog.Default()
+				if traceID, ok := TraceIDFromContext(c.Request.Context()); ok {
+					logger = logger.With(slog.String("trace_id", traceID))
+				}
+			}
+			logger.ErrorContext(c.Request.Context(), "panic recovered",
+				slog.String("panic", fmt.Sprint(recovered)),
+				slog.String("stack", string(stack)),
+			)
+			for _, hook := range hooks {
+				hook(c, recovered, stack)
+			}
+
+			if err, ok := recovered.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				c.Abort()
+				return
+			}
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			var e *Error
+			c.AbortWithStatusJSON(e.InternalServerError())
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	var out bytes.Buffer
+	var hooked any
+	var stack []byte
+	engine := newTestEngine()
+	engine.Use(Trace(), RequestLogger(slog.New(slog.NewJSONHandler(&out, nil))), Recovery(func(c *gin.Context, recovered any, s []byte) {
+		hooked, stack = recovered, s
+	}))
+	endpoint := New[testItem, testItem]("/panicking", engine.Group("/v1"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		panic("database password is hunter2")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/panicking/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+	var body Error
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "INTERNAL_SERVER_ERROR", body.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+
+	assert.Equal(t, "database password is hunter2", hooked)
+	assert.Contains(t, string(stack), "TestRecovery")
+
+	entries := logEntries(&out)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "panic recovered", entries[0]["msg"])
+	assert.Equal(t, "req-1", entries[0]["trace_id"])
+	assert.Equal(t, "database password is hunter2", entries[0]["panic"])
+	assert.Contains(t, entries[0]["stack"], "runtime/debug.Stack")
+	assert.Equal(t, float64(http.StatusInternalServerError), entries[1]["status"])
+}
+
+func TestRecoveryAfterWrite(t *testing.T) {
+	engine := newTestEngine()
+	engine.Use(Recovery())
+	engine.GET("/partial", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("late failure")
+	})
+
+	w := doRequest(engine, http.MethodGet, "/partial", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+}
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SecuritySchemeType is the kind of credentials a SecurityScheme accepts.
+type SecuritySchemeType string
+
+const (
+	APIKeySecurity SecuritySchemeType = "apiKey"
+	HTTPSecurity   SecuritySchemeType = "http"
+	OAuth2Security SecuritySchemeType = "oauth2"
+)
+
+// Authenticator verifies the credential sent with a request, an API key or a
+// bearer token, and returns the caller it belongs to.
+type Authenticator func(ctx context.Context, credential string) (*Principal, error)
+
+// SecurityScheme describes how requests authenticate. It is documented under
+// components/securitySchemes and enforced on the endpoints that require it
+// through WithSecurity.
+type SecurityScheme struct {
+	Type        SecuritySchemeType
+	Description string
+	// Header carries the API key of APIKeySecurity schemes.
+	Header string
+	// BearerFormat hints at the token format of HTTPSecurity schemes, ex: JWT.
+	BearerFormat string
+	// TokenURL and Scopes describe the OAuth2 client credentials flow.
+	TokenURL      string
+	Scopes        map[string]string
+	Authenticator Authenticator
+}
+
+// APIKeyScheme authenticates requests with an API key sent in header.
+func APIKeyScheme(header string, authenticator Authenticator) *SecurityScheme {
+	return &SecurityScheme{Type: APIKeySecurity, Header: header, Authenticator: authenticator}
+}
+
+// BearerScheme authenticates requests with an HTTP bearer token.
+func BearerScheme(bearerFormat string, authenticator Authenticator) *SecurityScheme {
+	return &SecurityScheme{Type: HTTPSecurity, BearerFormat: bearerFormat, Authenticator: authenticator}
+}
+
+// OAuth2ClientCredentialsScheme authenticates requests with bearer access
+// tokens issued by tokenURL through the client credentials flow. scopes maps
+// each scope to its description.
+func OAuth2ClientCredentialsScheme(tokenURL string, scopes map[string]string, authenticator Authenticator) *SecurityScheme {
+	return &SecurityScheme{Type: OAuth2Security, TokenURL: tokenURL, Scopes: scopes, Authenticator: authenticator}
+}
+
+// SecuritySchemes holds the security schemes declared for every endpoint by
+// name.
+var SecuritySchemes = make(map[string]*SecurityScheme)
+
+// RegisterSecurityScheme declares scheme under name so endpoints can require it.
+func RegisterSecurityScheme(name string, scheme *SecurityScheme) {
+	SecuritySchemes[name] = scheme
+}
+
+// UseSecuritySchemes makes schemes available to the handlers registered
+// afterwards on the endpoint, on top of the ones of SecuritySchemes, ex: to
+// scope them to an application. Schemes must be declared before the handlers
+// requiring them are registered.
+func (r *APIEndpoint[Req, Resp]) UseSecuritySchemes(schemes map[string]*SecurityScheme) *APIEndpoint[Req, Resp] {
+	r.schemes = schemes
+	return r
+}
+
+// securityScheme returns the scheme declared under name for the endpoint.
+func (r *APIEndpoint[Req, Resp]) securityScheme(name string) (*SecurityScheme, bool) {
+	if scheme, ok := r.schemes[name]; ok {
+		return scheme, true
+	}
+	scheme, ok := SecuritySchemes[name]
+	return scheme, ok
+}
+
+// checkSecurity panics when the security of config cannot be enforced, so
+// misconfigured endpoints fail when they are declared instead of failing open
+// or answering every request with an error.
+func (r *APIEndpoint[Req, Resp]) checkSecurity(config *EndpointConfigs) {
+	if len(config.Scopes) > 0 && len(config.Security) == 0 {
+		panic(fmt.Sprintf("router: WithScopes on %s requires WithSecurity", r.Path))
+	}
+	for _, requirement := range config.Security {
+		scheme, ok := r.securityScheme(requirement.Scheme)
+		if !ok || scheme == nil || scheme.Authenticator == nil {
+			panic(fmt.Sprintf("router: security scheme %s required on %s is not declared", requirement.Scheme, r.Path))
+		}
+	}
+}
+
+// SecurityRequirement names a security scheme and the scopes the caller must
+// hold.
+type SecurityRequirement struct {
+	Scheme string
+	Scopes []string
+}
+
+// WithSecurity requires requests to authenticate with the named scheme and to
+// hold scopes. When given several times any one of the requirements suffices.
+func WithSecurity(scheme string, scopes ...string) HandleOption {
+	return func(c *EndpointConfigs) {
+		c.Security = append(c.Security, SecurityRequirement{Scheme: scheme, Scopes: scopes})
+	}
+}
+
+// WithScopes adds scopes required on top of every security requirement of the
+// endpoint.
+func WithScopes(scopes ...string) HandleOption {
+	return func(c *EndpointConfigs) {
+		c.Scopes = append(c.Scopes, scopes...)
+	}
+}
+
+// securityRequirements returns the requirements of config with the endpoint
+// wide scopes merged in.
+func securityRequirements(config *EndpointConfigs) []SecurityRequirement {
+	requirements := make([]SecurityRequirement, 0, len(config.Security))
+	for _, requirement := range config.Security {
+		scopes := append(slices.Clone(requirement.Scopes), config.Scopes...)
+		sort.Strings(scopes)
+		requirement.Scopes = slices.Compact(scopes)
+		requirements = append(requirements, requirement)
+	}
+	return requirements
+}
+
+// credential extracts the credential the scheme expects from the request.
+func (s *SecurityScheme) credential(c *gin.Context) string {
+	if s.Type == APIKeySecurity {
+		return c.GetHeader(s.Header)
+	}
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// authenticate returns a middleware that lets requests through when they meet
+// one of the requirements, storing the caller in the request context. Requests
+// without valid credentials are answered with 401 and those lacking scopes with
+// 403. schemes holds the scheme of every requirement.
+func authenticate(requirements []SecurityRequirement, schemes map[string]*SecurityScheme) gin.HandlerFunc {
+	var e *Error
+	return func(c *gin.Context) {
+		statusCode, exception := e.Unauthorized()
+		challenge := ""
+		for _, requirement := range requirements {
+			scheme := schemes[requirement.Scheme]
+			if scheme.Type != APIKeySecurity {
+				challenge = "Bearer"
+			}
+			credential := scheme.credential(c)
+			if credential == "" {
+				continue
+			}
+			principal, err := scheme.Authenticator(c.Request.Context(), credential)
+			if err != nil || principal == nil {
+				continue
+			}
+			if !hasScopes(principal, requirement.Scopes) {
+				statusCode, exception = e.Forbidden()
+				continue
+			}
+			c.Request = c.Request.WithContext(ContextWithPrincipal(c.Request.Context(), principal))
+			c.Next()
+			return
+		}
+		if statusCode == http.StatusUnauthorized && challenge != "" {
+			c.Header("WWW-Authenticate", challenge)
+		}
+		c.AbortWithStatusJSON(statusCode, exception)
+	}
+}
+
+func hasScopes(principal *Principal, scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(principal.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildSecuritySchemes documents schemes as OpenAPI security scheme objects.
+func buildSecuritySchemes(schemes map[string]*SecurityScheme) Map {
+	built := make(Map)
+	for name, scheme := range schemes {
+		object := Map{"type": string(scheme.Type)}
+		if scheme.Description != "" {
+			object["description"] = scheme.Description
+		}
+		switch scheme.Type {
+		case APIKeySecurity:
+			object["in"] = "header"
+			object["name"] = scheme.Header
+		case HTTPSecurity:
+			object["scheme"] = "bearer"
+			if scheme.BearerFormat != "" {
+				object["bearerFormat"] = scheme.BearerFormat
+			}
+		case OAuth2Security:
+			scopes := make(Map)
+			for scope, description := range scheme.Scopes {
+				scopes[scope] = description
+			}
+			object["flows"] = Map{
+				"clientCredentials": Map{
+					"tokenUrl": scheme.TokenURL,
+					"scopes":   scopes,
+				},
+			}
+		}
+		built[name] = object
+	}
+	return built
+}
+
+// buildSecurity documents the requirements of an operation.
+func buildSecurity(requirements []SecurityRequirement) []Map {
+	security := make([]Map, 0, len(requirements))
+	for _, requirement := range requirements {
+		scopes := requirement.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		security = append(security, Map{requirement.Scheme: scopes})
+	}
+	return security
+}
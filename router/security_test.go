@@ -0,0 +1,147 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func staticAuthenticator(credentials map[string]*Principal) Authenticator {
+	return func(_ context.Context, credential string) (*Principal, error) {
+		principal, ok := credentials[credential]
+		if !ok {
+			return nil, errors.New("unknown credential")
+		}
+		return principal, nil
+	}
+}
+
+func TestSecurityEnforcement(t *testing.T) {
+	RegisterSecurityScheme("testKey", APIKeyScheme("X-API-Key", staticAuthenticator(map[string]*Principal{
+		"k1": {Subject: "service-a"},
+	})))
+	RegisterSecurityScheme("testBearer", BearerScheme("JWT", staticAuthenticator(map[string]*Principal{
+		"reader": {Subject: "alice", Scopes: []string{"product.read"}},
+		"writer": {Subject: "bob", Scopes: []string{"product.read", "product.write"}},
+	})))
+
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/secured", engine.Group("/v1"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		principal, _ := PrincipalFromContext(params.Context())
+		return nil, &testItem{Name: &principal.Subject}
+	}, WithSecurity("testBearer", "product.read"), WithSecurity("testKey"))
+	endpoint.HandleDelete("/:id", func(params *RequestParams) *Err {
+		return nil
+	}, WithSecurity("testBearer"), WithScopes("product.write"))
+
+	request := func(method, target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject missing credentials", func(t *testing.T) {
+		w := request(http.MethodGet, "/v1/secured/1", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "UNAUTHORIZED_ERROR")
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("should reject invalid credentials", func(t *testing.T) {
+		w := request(http.MethodGet, "/v1/secured/1", map[string]string{"Authorization": "Bearer unknown"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should accept any of the requirements", func(t *testing.T) {
+		w := request(http.MethodGet, "/v1/secured/1", map[string]string{"Authorization": "Bearer reader"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name":"alice"}`, w.Body.String())
+
+		w = request(http.MethodGet, "/v1/secured/1", map[string]string{"X-API-Key": "k1"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name":"service-a"}`, w.Body.String())
+	})
+
+	t.Run("should forbid callers lacking scopes", func(t *testing.T) {
+		w := request(http.MethodDelete, "/v1/secured/1", map[string]string{"Authorization": "Bearer reader"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "FORBIDDEN_ERROR")
+
+		w = request(http.MethodDelete, "/v1/secured/1", map[string]string{"Authorization": "Bearer writer"})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("should document the requirements", func(t *testing.T) {
+		spec, err := NewOpenAPI("test", "1.0", "").
+			SetSecuritySchemes(map[string]*SecurityScheme{"testKey": SecuritySchemes["testKey"], "testBearer": SecuritySchemes["testBearer"]}).
+			SetEndpoints(map[string]*Endpoint{"/v1/secured/{id}": Endpoints["/v1/secured/{id}"]}).
+			Build()
+		assert.Nil(t, err)
+
+		schemes := spec["components"].(Map)["securitySchemes"].(Map)
+		assert.Equal(t, Map{"type": "apiKey", "in": "header", "name": "X-API-Key"}, schemes["testKey"])
+		assert.Equal(t, Map{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}, schemes["testBearer"])
+
+		operations := spec["paths"].(Map)["/v1/secured/{id}"].(Map)
+		assert.Equal(t, []Map{{"testBearer": []string{"product.read"}}, {"testKey": []string{}}}, operations["get"].(Map)["security"])
+		assert.Equal(t, []Map{{"testBearer": []string{"product.write"}}}, operations["delete"].(Map)["security"])
+		assert.Contains(t, operations["delete"].(Map)["responses"], "401")
+		assert.Contains(t, operations["delete"].(Map)["responses"], "403")
+	})
+}
+
+func TestOAuth2SecurityScheme(t *testing.T) {
+	scheme := OAuth2ClientCredentialsScheme("https://auth.example.com/token", map[string]string{"product.read": "read products"}, nil)
+
+	assert.Equal(t, Map{"oauth": Map{
+		"type": "oauth2",
+		"flows": Map{"clientCredentials": Map{
+			"tokenUrl": "https://auth.example.com/token",
+			"scopes":   Map{"product.read": "read products"},
+		}},
+	}}, buildSecuritySchemes(map[string]*SecurityScheme{"oauth": scheme}))
+}
+
+func TestSecurityChecks(t *testing.T) {
+	engine := newTestEngine()
+	processor := func(params *RequestParams) (*Err, *testItem) {
+		return nil, &testItem{}
+	}
+
+	t.Run("should panic on scopes without security", func(t *testing.T) {
+		endpoint := New[testItem, testItem]("/scoped", engine.Group("/v1"))
+		assert.PanicsWithValue(t, "router: WithScopes on /scoped requires WithSecurity", func() {
+			endpoint.HandleRead("/:id", processor, WithScopes("product.read"))
+		})
+	})
+
+	t.Run("should panic on undeclared schemes", func(t *testing.T) {
+		endpoint := New[testItem, testItem]("/undeclared", engine.Group("/v1"))
+		assert.PanicsWithValue(t, "router: security scheme missing required on /undeclared is not declared", func() {
+			endpoint.HandleRead("/:id", processor, WithSecurity("missing"))
+		})
+	})
+
+	t.Run("should use the schemes of the endpoint", func(t *testing.T) {
+		schemes := map[string]*SecurityScheme{"local": APIKeyScheme("X-Local-Key", staticAuthenticator(map[string]*Principal{
+			"k1": {Subject: "service-a"},
+		}))}
+		endpoint := New[testItem, testItem]("/local", engine.Group("/v1")).UseSecuritySchemes(schemes)
+		endpoint.HandleRead("/:id", processor, WithSecurity("local"))
+		assert.NotContains(t, SecuritySchemes, "local")
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/local/1", nil)
+		req.Header.Set("X-Local-Key", "k1")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
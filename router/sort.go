@@ -0,0 +1,47 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// SortKey is one attribute of the sort query parameter. sort=-price,name is
+// parsed into price descending followed by name ascending.
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+func invalidSortErr(field string) *Err {
+	return &Err{
+		ErrCode:    "INVALID_SORT_ERROR",
+		ErrReason:  "The field <" + field + "> does not exist",
+		Message:    "Invalid sort field <" + field + ">",
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+// parseSort parses the sort query parameter, validating each attribute against
+// the fields of t. Dotted paths address nested attributes.
+func parseSort(raw string, t reflect.Type) ([]SortKey, *Err) {
+	raw = strings.ReplaceAll(raw, " ", "")
+	if raw == "" {
+		return nil, nil
+	}
+	keys := make([]SortKey, 0)
+	for _, field := range strings.Split(raw, ",") {
+		key := SortKey{Field: field}
+		switch {
+		case strings.HasPrefix(field, "-"):
+			key = SortKey{Field: field[1:], Descending: true}
+		case strings.HasPrefix(field, "+"):
+			key = SortKey{Field: field[1:]}
+		}
+		if _, ok := resolveFieldKind(t, strings.Split(key.Field, ".")); !ok || key.Field == "" {
+			return nil, invalidSortErr(key.Field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
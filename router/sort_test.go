@@ -0,0 +1,49 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	productType := reflect.TypeOf(new(filterProduct))
+
+	keys, perr := parseSort("-price, name,+specification.adress.name", productType)
+	assert.Nil(t, perr)
+	assert.Equal(t, []SortKey{
+		{Field: "price", Descending: true},
+		{Field: "name"},
+		{Field: "specification.adress.name"},
+	}, keys)
+
+	keys, perr = parseSort("", productType)
+	assert.Nil(t, perr)
+	assert.Empty(t, keys)
+
+	_, perr = parseSort("-colour", productType)
+	assert.Equal(t, "INVALID_SORT_ERROR", perr.ErrCode)
+	assert.Equal(t, http.StatusBadRequest, perr.StatusCode)
+
+	_, perr = parseSort("name,,price", productType)
+	assert.Equal(t, "INVALID_SORT_ERROR", perr.ErrCode)
+}
+
+func TestHandleListSort(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/sorted", engine.Group("/v1"))
+	var received []SortKey
+	endpoint.HandleList("", func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+		received = params.Sort
+		return []*testItem{}, nil, 0, 0
+	})
+
+	w := doRequest(engine, http.MethodGet, "/v1/sorted?sort=-price,name", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []SortKey{{Field: "price", Descending: true}, {Field: "name"}}, received)
+
+	w = doRequest(engine, http.MethodGet, "/v1/sorted?sort=colour", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_SORT_ERROR")
+}
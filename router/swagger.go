@@ -1,14 +1,29 @@
 package router
 
 import (
+	"encoding/json"
 	"errors"
+	"gopkg.in/yaml.v3"
+	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
 type Map map[string]any
+
+// OpenAPIVersion selects the OpenAPI release a document is written for.
+type OpenAPIVersion string
+
+const (
+	OpenAPI30 OpenAPIVersion = "3.0.3"
+	// OpenAPI31 documents schemas with JSON Schema 2020-12 semantics: nullable
+	// fields list "null" among their types and examples are arrays.
+	OpenAPI31 OpenAPIVersion = "3.1.0"
+)
+
 type OpenAPI struct {
 	swagger     Map
 	title       string
@@ -16,11 +31,13 @@ type OpenAPI struct {
 	description string
 	paths       Map
 	endpoints   map[string]*Endpoint
+	security    map[string]*SecurityScheme
+	schemas     *Schema
 }
 
 func NewOpenAPI(title, version, description string) *OpenAPI {
 	swagger := make(Map)
-	swagger["openapi"] = "3.0.0"
+	swagger["openapi"] = string(OpenAPI30)
 	swagger["info"] = Map{
 		"title":       title,
 		"version":     version,
@@ -32,9 +49,23 @@ func NewOpenAPI(title, version, description string) *OpenAPI {
 		version:     version,
 		description: description,
 		paths:       make(Map),
+		schemas:     &Schema{},
 	}
 }
 
+// SetVersion selects the OpenAPI version of the document, OpenAPI30 by default.
+func (o *OpenAPI) SetVersion(version OpenAPIVersion) *OpenAPI {
+	o.swagger["openapi"] = string(version)
+	o.schemas.jsonSchema2020 = version == OpenAPI31
+	return o
+}
+
+// SetSecuritySchemes documents schemes under components/securitySchemes.
+func (o *OpenAPI) SetSecuritySchemes(schemes map[string]*SecurityScheme) *OpenAPI {
+	o.security = schemes
+	return o
+}
+
 func (o *OpenAPI) SetEndpoints(endpoints map[string]*Endpoint) *OpenAPI {
 	o.endpoints = endpoints
 	return o
@@ -45,10 +76,22 @@ func (o *OpenAPI) Build() (Map, error) {
 		return nil, errors.New("no endpoints provided")
 	}
 
-	for _, endpoint := range o.endpoints {
+	// Paths are built in order so that component names are stable between runs.
+	paths := make([]string, 0, len(o.endpoints))
+	for path := range o.endpoints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		endpoint := o.endpoints[path]
 		o.paths[endpoint.Path] = o.buildPathItem(endpoint)
 	}
 	o.swagger["paths"] = o.paths
+	components := Map{"schemas": o.schemas.Components()}
+	if len(o.security) > 0 {
+		components["securitySchemes"] = buildSecuritySchemes(o.security)
+	}
+	o.swagger["components"] = components
 	return o.swagger, nil
 }
 
@@ -65,28 +108,30 @@ func (o *OpenAPI) buildPathItem(endpoint *Endpoint) Map {
 }
 
 func (o *OpenAPI) buildOperation(method Method) Map {
-	statusCode := "200"
+	statusCode := http.StatusOK
 	if method.StatusCode != nil {
-		statusCode = strconv.Itoa(*method.StatusCode)
+		statusCode = *method.StatusCode
 	}
 
+	responses := make(Map)
+	for _, code := range append([]int{statusCode}, method.Configs.successCodes...) {
+		responses[strconv.Itoa(code)] = o.buildResponse(method, code)
+	}
 	operation := Map{
-		"responses": Map{
-			statusCode: o.buildResponse(),
-		},
+		"responses": responses,
 	}
 
 	if method.HTTPMethod != "GET" && method.Request != nil {
-		operation["requestBody"] = o.buildRequestBody(method.Request)
+		operation["requestBody"] = o.buildRequestBody(method.Request, method.Configs.Consumes)
 	}
 
-	if method.Response != nil {
-		schema := Schema{}
-		operation["responses"].(Map)["200"].(Map)["content"].(Map)["application/json"].(Map)["schema"] = schema.Build(method.Response, "response")
+	for status, response := range o.buildErrResponses(o.errorResponses(method)) {
+		operation["responses"].(Map)[status] = response
 	}
-	tags := method.Tags
-
-	tags = append(method.Configs.Tags)
+	if len(method.Configs.Security) > 0 {
+		operation["security"] = buildSecurity(securityRequirements(&method.Configs))
+	}
+	tags := method.Configs.Tags
 	if tags != nil {
 		operation["tags"] = tags
 	}
@@ -102,45 +147,145 @@ func (o *OpenAPI) buildOperation(method Method) Map {
 	return operation
 }
 
-func (o *OpenAPI) buildErrResponse(code, reason, message string) Map {
+// errorResponses lists the errors method can answer with: the ones raised by
+// worx itself, depending on how the endpoint is set up, followed by the ones
+// documented with WithErrorResponses.
+func (o *OpenAPI) errorResponses(method Method) []ErrorResponse {
+	var e *Error
+	var va *Validation
+	responses := make([]ErrorResponse, 0)
+	add := func(statusCode int, example Error) {
+		responses = append(responses, ErrorResponse{StatusCode: statusCode, Example: example})
+	}
+
+	hasBody := method.HTTPMethod != "GET" && method.HTTPMethod != "DELETE" && method.Request != nil
+	if hasBody {
+		add(http.StatusBadRequest, Error{Code: "REQUIRED_FIELD_ERR", Reason: BADREQUEST, Message: "The field <name> is required"})
+		if len(method.Configs.Consumes) > 1 {
+			add(va.unsupportedMediaType(method.Configs.Consumes))
+		} else {
+			add(va.contentType())
+		}
+	}
+	if method.Configs.Pagination.Mode != "" {
+		add(http.StatusBadRequest, invalidFilterErr("The attribute <color> does not exist", "Invalid filter attribute <color>").toError())
+	}
+	if len(method.Configs.Security) > 0 {
+		add(e.Unauthorized())
+		add(e.Forbidden())
+	}
+	if len(method.Configs.PathParams) > 0 {
+		add(e.ResourceNotFound())
+	}
+	add(e.MethodNotAllowed())
+	add(e.InternalServerError())
+	return append(responses, method.Configs.ErrorResponses...)
+}
+
+// buildErrResponses documents responses by status code, each with the Error
+// schema and one example per error code.
+func (o *OpenAPI) buildErrResponses(responses []ErrorResponse) Map {
+	built := make(Map)
+	for _, response := range responses {
+		status := strconv.Itoa(response.StatusCode)
+		existing, ok := built[status].(Map)
+		if !ok {
+			existing = o.buildErrResponse(response.StatusCode)
+			built[status] = existing
+		}
+		if response.Description != "" {
+			existing["description"] = response.Description
+		}
+		if response.Example.Code != "" {
+			examples := existing["content"].(Map)["application/json"].(Map)["examples"].(Map)
+			examples[response.Example.Code] = Map{
+				"summary": response.Example.Message,
+				"value":   response.Example,
+			}
+		}
+	}
+	return built
+}
+
+func (o *OpenAPI) buildErrResponse(statusCode int) Map {
 	return Map{
-		"description": message,
+		"description": http.StatusText(statusCode),
 		"content": Map{
 			"application/json": Map{
-				"schema": Map{
-					"type": "object",
-					"example": Map{
-						"code":    code,
-						"reason":  reason,
-						"message": message,
-					},
-				},
+				"schema":   o.schemas.Build(new(Error), "response"),
+				"examples": Map{},
 			},
 		},
 	}
 }
 
-func (o *OpenAPI) buildResponse() Map {
-	return Map{
-		"description": "Successful operation",
-		"content": Map{
-			"application/json": Map{
-				"schema": Map{
-					"type": "object",
-				},
+// buildResponse documents a success response. List responses are arrays of
+// the response type and carry the paging headers; 204 responses have no body.
+func (o *OpenAPI) buildResponse(method Method, statusCode int) Map {
+	response := Map{
+		"description": http.StatusText(statusCode),
+	}
+	if method.Response == nil || statusCode == http.StatusNoContent {
+		return response
+	}
+	schema := o.schemas.Build(method.Response, "response")
+	if method.Configs.Pagination.Mode != "" {
+		schema = Map{"type": "array", "items": schema}
+		response["headers"] = Map{
+			"X-Total-Count": Map{
+				"description": "number of resources matching the request, when known",
+				"schema":      Map{"type": "integer"},
 			},
-		},
+			"X-Result-Count": Map{
+				"description": "number of resources in the response",
+				"schema":      Map{"type": "integer"},
+			},
+			"Link": Map{
+				"description": "links to the next, previous and first pages",
+				"schema":      Map{"type": "string"},
+			},
+		}
+	}
+	response["content"] = Map{
+		"application/json": Map{"schema": schema},
 	}
+	return response
 }
 
-func (o *OpenAPI) buildRequestBody(request interface{}) Map {
-	s := Schema{}
-	requestSchema := s.Build(request, "request")
+func (o *OpenAPI) buildRequestBody(request interface{}, consumes []string) Map {
+	requestSchema := o.schemas.Build(request, "request")
+	if len(consumes) == 0 {
+		consumes = []string{MediaTypeJSON}
+	}
+	content := make(Map)
+	for _, mediaType := range consumes {
+		switch mediaType {
+		case MediaTypeJSONPatch:
+			content[mediaType] = Map{"schema": jsonPatchSchema()}
+		default:
+			content[mediaType] = Map{"schema": requestSchema}
+		}
+	}
 	return Map{
 		"required": true,
-		"content": Map{
-			"application/json": Map{
-				"schema": requestSchema,
+		"content":  content,
+	}
+}
+
+func jsonPatchSchema() Map {
+	return Map{
+		"type": "array",
+		"items": Map{
+			"type":     "object",
+			"required": []string{"op", "path"},
+			"properties": Map{
+				"op": Map{
+					"type": "string",
+					"enum": []string{"add", "remove", "replace", "move", "copy", "test"},
+				},
+				"path":  Map{"type": "string"},
+				"from":  Map{"type": "string"},
+				"value": Map{},
 			},
 		},
 	}
@@ -177,189 +322,309 @@ func (o *OpenAPI) buildPathParamParameter(param AllowedFields) Map {
 }
 
 func (o *OpenAPI) buildParam(param AllowedFields, t string) Map {
+	schema := param.Schema
+	if schema == nil && param.field != nil {
+		schema = o.schemas.paramSchema(*param.field)
+	}
+	if schema == nil {
+		schema = Map{"type": "string"}
+	}
 	return Map{
 		"in":          t,
 		"name":        param.Name,
 		"description": param.Description,
 		"required":    param.Required,
-		"schema": Map{
-			"type": "string",
-		},
+		"schema":      schema,
 	}
 }
 
-type Schema struct{}
-
-func (sc *Schema) Build(input interface{}, structType string) map[string]interface{} {
-
-	if reflect.TypeOf(input).Kind() == reflect.Ptr {
-		input = reflect.ValueOf(input).Elem().Interface()
+// paramSchema documents the parameter bound into field by WithParams.
+// Constraints on list parameters apply to their items.
+func (sc *Schema) paramSchema(field reflect.StructField) Map {
+	t := indirect(field.Type)
+	item := t
+	if t.Kind() == reflect.Slice && !reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		item = indirect(t.Elem())
 	}
-	t := reflect.TypeOf(input)
-
-	schema := make(map[string]interface{})
-	schema["type"] = "object"
-
-	properties := make(map[string]interface{})
-	required := make([]string, 0)
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		fieldName := sc.getFieldName(field)
-		if len(fieldName) == 0 {
-			continue
-		}
-		fieldName = field.Tag.Get("json")
-		required = sc.checkRequired(field, required)
-		if len(field.Tag.Get("binding")) > 0 {
-			binding := field.Tag.Get("binding")
-			switch binding {
-			case "ignore":
-				if structType == "request" {
-					continue
-				}
 
-			}
+	itemField := field
+	itemField.Type = item
+	itemSchema := sc.buildFieldSchema(itemField, true)
+	delete(itemSchema, "description")
+	if item == durationType || item != timeType && reflect.PointerTo(item).Implements(textUnmarshalerType) {
+		itemSchema["type"] = "string"
+		delete(itemSchema, "format")
+		if item == durationType {
+			itemSchema["format"] = "duration"
 		}
-
-		fieldSchema := sc.buildFieldSchema(field, structType)
-
-		properties[fieldName] = fieldSchema
-
-		required = sc.checkRequired(field, required)
 	}
 
-	schema["properties"] = properties
-
-	if len(required) > 0 {
-		schema["required"] = required
+	schema := itemSchema
+	if item != t {
+		schema = Map{"type": "array", "items": itemSchema}
+	}
+	if fallback, ok := defaultParam(field); ok {
+		schema["default"] = fallback
 	}
-
 	return schema
+}
 
+// Schema builds JSON schemas out of Go types. Named struct types are collected
+// as components and referenced with $ref, which keeps shared types defined once
+// and lets recursive types terminate. A type with binding:"ignore" fields, which
+// clients may not send, gets a separate request variant without them. Fields
+// tagged nullable:"true" accept null.
+type Schema struct {
+	components Map
+	names      map[schemaKey]string
+	types      map[string]reflect.Type
+	// jsonSchema2020 writes OpenAPI 3.1 schemas instead of OpenAPI 3.0 ones.
+	jsonSchema2020 bool
 }
 
-func (sc *Schema) buildFieldSchema(field reflect.StructField, sType string) map[string]interface{} {
-	fieldSchema := make(map[string]interface{})
+type schemaKey struct {
+	t       reflect.Type
+	request bool
+}
 
-	fieldName := field.Tag.Get("json")
-	if fieldName == "" {
-		fieldName = field.Name
+// YAML returns the document encoded as YAML.
+func (m Map) YAML() ([]byte, error) {
+	// Going through JSON keeps the field names set by json tags.
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, err
 	}
+	return yaml.Marshal(doc)
+}
 
-	fieldSchema["name"] = fieldName
-	description := field.Tag.Get("description")
-	if description != "" {
-		fieldSchema["description"] = description
+// Build returns the schema of input, a value or pointer. structType is either
+// "request" or "response".
+func (sc *Schema) Build(input interface{}, structType string) Map {
+	t := reflect.TypeOf(input)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+	return sc.typeSchema(t, structType == "request")
+}
 
-	fieldType := field.Type
-	if fieldType.Kind() == reflect.Ptr {
-		fieldType = fieldType.Elem()
+// Components returns the schemas referenced by the schemas built so far, keyed
+// by component name.
+func (sc *Schema) Components() Map {
+	if sc.components == nil {
+		return Map{}
 	}
+	return sc.components
+}
 
-	switch fieldType.Kind() {
+func (sc *Schema) typeSchema(t reflect.Type, request bool) Map {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return Map{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return Map{"$ref": "#/components/schemas/" + sc.component(t, request)}
+	case t.Kind() == reflect.Struct:
+		return sc.objectSchema(t, request)
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8:
+		return Map{"type": "string", "format": "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return Map{"type": "array", "items": sc.typeSchema(t.Elem(), request)}
+	case t.Kind() == reflect.Map:
+		return Map{"type": "object", "additionalProperties": sc.typeSchema(t.Elem(), request)}
+	case t.Kind() == reflect.Interface:
+		return Map{}
+	}
+	return sc.getPrimitiveTypeSchema(t)
+}
 
-	case reflect.Struct:
-		if fieldType == reflect.TypeOf(time.Time{}) {
-			fieldSchema["type"] = "string"
-			fieldSchema["format"] = "date-time"
+// component registers the schema of the named struct t and returns its name.
+// The name is reserved before the properties are built so that recursive
+// references resolve to it.
+func (sc *Schema) component(t reflect.Type, request bool) string {
+	if sc.components == nil {
+		sc.components = make(Map)
+		sc.names = make(map[schemaKey]string)
+		sc.types = make(map[string]reflect.Type)
+	}
+	request = request && hasIgnoredFields(t, make(map[reflect.Type]bool))
+	key := schemaKey{t: t, request: request}
+	if name, ok := sc.names[key]; ok {
+		return name
+	}
 
-		} else {
-			nestedSchema := sc.Build(reflect.New(fieldType).Elem().Interface(), sType)
-			fieldSchema["type"] = "object"
-			fieldSchema["properties"] = nestedSchema["properties"]
-			// If nested struct has required fields, include them in the parent schema
-			if requiredFields, ok := nestedSchema["required"]; ok {
-				if required, ok := requiredFields.([]string); ok {
-					fieldSchema["required"] = required
-				}
-			}
+	base := schemaName(t)
+	if request {
+		base += "_Input"
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := sc.types[name]; !taken {
+			break
 		}
-	case reflect.Slice:
-
-		sliceType := fieldType.Elem()
-		if sliceType.Kind() == reflect.Ptr {
+		name = base + strconv.Itoa(i)
+	}
+	sc.names[key] = name
+	sc.types[name] = t
+	sc.components[name] = Map{}
+	sc.components[name] = sc.objectSchema(t, request)
+	return name
+}
 
-			sliceType = sliceType.Elem()
-		}
-		if sliceType.Kind() == reflect.Struct {
+func (sc *Schema) objectSchema(t reflect.Type, request bool) Map {
+	properties := make(Map)
+	required := make([]string, 0)
+	sc.addProperties(t, request, properties, &required)
 
-			nestedSchema := sc.Build(reflect.New(sliceType).Elem().Interface(), sType)
-			fieldSchema["type"] = "array"
-			fieldSchema["items"] = nestedSchema
-		} else if sliceType.Kind() == reflect.Slice {
+	schema := Map{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
 
-			nestedSchema := sc.buildNestedListSchema(sliceType)
-			fieldSchema["type"] = "array"
-			fieldSchema["items"] = nestedSchema
-		} else {
+func (sc *Schema) addProperties(t reflect.Type, request bool, properties Map, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && strings.Split(tag, ",")[0] == "" && fieldType.Kind() == reflect.Struct {
+			sc.addProperties(fieldType, request, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		ignored := hasBinding(field, "ignore")
+		if ignored && request {
+			continue
+		}
 
-			fieldSchema["type"] = "array"
-			fieldSchema["items"] = sc.getPrimitiveTypeSchema(sliceType)
+		name := jsonFieldName(field)
+		fieldSchema := sc.buildFieldSchema(field, request)
+		if ignored {
+			fieldSchema["readOnly"] = true
 		}
-	default:
-		fieldSchema = sc.getPrimitiveTypeSchema(fieldType)
-		enums := field.Tag.Get("enums")
-		if enums != "" {
-			enumValues := strings.Split(enums, ",")
-			fieldSchema["enum"] = enumValues
+		properties[name] = fieldSchema
+		if hasBinding(field, "required") {
+			*required = append(*required, name)
 		}
 	}
+}
 
-	regex := field.Tag.Get("regex")
-	if regex != "" {
+func (sc *Schema) buildFieldSchema(field reflect.StructField, request bool) Map {
+	fieldSchema := sc.typeSchema(field.Type, request)
 
+	if enums := field.Tag.Get("enums"); enums != "" {
+		fieldSchema["enum"] = strings.Split(enums, ",")
+	}
+	if regex := field.Tag.Get("regex"); regex != "" {
 		fieldSchema["pattern"] = regex
 	}
-
+	if description := field.Tag.Get("description"); description != "" {
+		fieldSchema["description"] = description
+	}
+	nullable := field.Tag.Get("nullable") == "true"
 	example := field.Tag.Get("example")
-	if example != "" {
 
-		fieldSchema["example"] = example
+	if sc.jsonSchema2020 {
+		if example != "" {
+			fieldSchema["examples"] = []string{example}
+		}
+		if nullable {
+			if fieldType, ok := fieldSchema["type"].(string); ok {
+				fieldSchema["type"] = []string{fieldType, "null"}
+			} else {
+				fieldSchema = Map{"anyOf": []Map{fieldSchema, {"type": "null"}}}
+			}
+		}
+		return fieldSchema
 	}
 
+	if example != "" {
+		fieldSchema["example"] = example
+	}
+	if nullable {
+		fieldSchema["nullable"] = true
+	}
+	// Siblings of $ref are ignored by OpenAPI 3.0, wrap the reference to keep
+	// the field annotations.
+	if ref, ok := fieldSchema["$ref"]; ok && len(fieldSchema) > 1 {
+		delete(fieldSchema, "$ref")
+		fieldSchema["allOf"] = []Map{{"$ref": ref}}
+	}
 	return fieldSchema
 }
 
-func (sc *Schema) getPrimitiveTypeSchema(fieldType reflect.Type) map[string]interface{} {
-	schema := make(map[string]interface{})
+func (sc *Schema) getPrimitiveTypeSchema(fieldType reflect.Type) Map {
 	switch fieldType.Kind() {
-	case reflect.String:
-		schema["type"] = "string"
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		schema["type"] = "integer"
-		schema["format"] = "int64"
+		return Map{"type": "integer", "format": "int64"}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		schema["type"] = "integer"
-		schema["format"] = "uint64"
-	case reflect.Float32, reflect.Float64:
-		schema["type"] = "number"
-		schema["format"] = "float"
+		return Map{"type": "integer", "format": "uint64"}
+	case reflect.Float32:
+		return Map{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return Map{"type": "number", "format": "double"}
 	case reflect.Bool:
-		schema["type"] = "boolean"
-	default:
-		schema["type"] = "string"
-
+		return Map{"type": "boolean"}
 	}
-	return schema
+	return Map{"type": "string"}
 }
 
-func (sc *Schema) buildNestedListSchema(sliceType reflect.Type) map[string]interface{} {
-	nestedSchema := make(map[string]interface{})
-	nestedSchema["type"] = "array"
-	nestedSchema["items"] = sc.getPrimitiveTypeSchema(sliceType.Elem())
-	return nestedSchema
-}
-func (sc *Schema) getFieldName(field reflect.StructField) string {
-	if len(field.Tag.Get("json")) > 0 {
-		return field.Tag.Get("json")
+func hasBinding(field reflect.StructField, rule string) bool {
+	for _, binding := range strings.Split(field.Tag.Get("binding"), ",") {
+		if strings.TrimSpace(binding) == rule {
+			return true
+		}
 	}
-	return field.Name
+	return false
 }
-func (sc *Schema) checkRequired(field reflect.StructField, required []string) []string {
-	if binding := field.Tag.Get("binding"); len(binding) > 0 && binding == "required" {
-		required = append(required, sc.getFieldName(field))
+
+// hasIgnoredFields reports whether t, or a type it contains, has binding:"ignore"
+// fields, in which case its request and response schemas differ.
+func hasIgnoredFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || seen[t] {
+		return false
 	}
-	return required
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("json") == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if hasBinding(field, "ignore") || hasIgnoredFields(field.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+var qualifiedIdentifier = regexp.MustCompile(`[\w./-]*\.`)
+var nonNameChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// schemaName derives a component name from a Go type name. Instances of generic
+// types are named after the type and its arguments without package paths, so
+// APIEndpoint[main.Product,main.Product] becomes APIEndpoint_Product_Product.
+func schemaName(t reflect.Type) string {
+	name := qualifiedIdentifier.ReplaceAllString(t.Name(), "")
+	name = nonNameChars.ReplaceAllString(name, "_")
+	return strings.Trim(name, "_")
 }
@@ -0,0 +1,253 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaCategory struct {
+	Id     *string           `json:"id" binding:"ignore"`
+	Name   *string           `json:"name,omitempty" binding:"required" description:"category name"`
+	Parent *schemaCategory   `json:"parent,omitempty"`
+	Sub    []*schemaCategory `json:"subCategories"`
+}
+
+type schemaProduct struct {
+	Name      *string         `json:"name" binding:"required"`
+	Status    *string         `json:"status" enums:"active,retired"`
+	Category  *schemaCategory `json:"category" description:"main category"`
+	Tags      []string        `json:"tags"`
+	Attrs     map[string]int  `json:"attributes"`
+	CreatedAt *time.Time      `json:"createdAt"`
+	internal  string
+}
+
+type schemaWrapper[T any] struct {
+	Item *T `json:"item"`
+}
+
+func TestSchemaComponents(t *testing.T) {
+	sc := &Schema{}
+	response := sc.Build(new(schemaProduct), "response")
+	request := sc.Build(new(schemaProduct), "request")
+	components := sc.Components()
+
+	t.Run("should reference named types", func(t *testing.T) {
+		assert.Equal(t, Map{"$ref": "#/components/schemas/schemaProduct"}, response)
+		assert.Equal(t, Map{"$ref": "#/components/schemas/schemaProduct_Input"}, request)
+	})
+
+	t.Run("should define each component once", func(t *testing.T) {
+		names := make([]string, 0, len(components))
+		for name := range components {
+			names = append(names, name)
+		}
+		assert.ElementsMatch(t, []string{"schemaProduct", "schemaProduct_Input", "schemaCategory", "schemaCategory_Input"}, names)
+	})
+
+	t.Run("should build properties from json names", func(t *testing.T) {
+		product := components["schemaProduct"].(Map)
+		properties := product["properties"].(Map)
+		assert.Equal(t, []string{"name"}, product["required"])
+		assert.NotContains(t, properties, "internal")
+		assert.Equal(t, Map{"type": "string", "enum": []string{"active", "retired"}}, properties["status"])
+		assert.Equal(t, Map{"type": "array", "items": Map{"type": "string"}}, properties["tags"])
+		assert.Equal(t, Map{"type": "object", "additionalProperties": Map{"type": "integer", "format": "int64"}}, properties["attributes"])
+		assert.Equal(t, Map{"type": "string", "format": "date-time"}, properties["createdAt"])
+		assert.Equal(t, Map{
+			"description": "main category",
+			"allOf":       []Map{{"$ref": "#/components/schemas/schemaCategory"}},
+		}, properties["category"])
+	})
+
+	t.Run("should resolve recursive types", func(t *testing.T) {
+		category := components["schemaCategory"].(Map)["properties"].(Map)
+		assert.Equal(t, Map{"$ref": "#/components/schemas/schemaCategory"}, category["parent"])
+		assert.Equal(t, Map{"type": "array", "items": Map{"$ref": "#/components/schemas/schemaCategory"}}, category["subCategories"])
+		assert.Contains(t, category, "name")
+	})
+
+	t.Run("should leave ignored fields out of request variants", func(t *testing.T) {
+		responseCategory := components["schemaCategory"].(Map)["properties"].(Map)
+		requestCategory := components["schemaCategory_Input"].(Map)["properties"].(Map)
+		assert.Equal(t, Map{"type": "string", "readOnly": true}, responseCategory["id"])
+		assert.NotContains(t, requestCategory, "id")
+		assert.Equal(t, Map{"$ref": "#/components/schemas/schemaCategory_Input"}, requestCategory["parent"])
+
+		requestProduct := components["schemaProduct_Input"].(Map)["properties"].(Map)
+		assert.Equal(t, "#/components/schemas/schemaCategory_Input", requestProduct["category"].(Map)["allOf"].([]Map)[0]["$ref"])
+	})
+
+	t.Run("should share types without ignored fields", func(t *testing.T) {
+		sc := &Schema{}
+		assert.Equal(t, sc.Build(new(filterSpec), "response"), sc.Build(new(filterSpec), "request"))
+	})
+}
+
+func TestSchemaName(t *testing.T) {
+	assert.Equal(t, "schemaProduct", schemaName(reflect.TypeOf(schemaProduct{})))
+	assert.Equal(t, "schemaWrapper_schemaProduct", schemaName(reflect.TypeOf(schemaWrapper[schemaProduct]{})))
+	assert.Equal(t, "APIEndpoint_testItem_testItem", schemaName(reflect.TypeOf(APIEndpoint[testItem, testItem]{})))
+	assert.Equal(t, "Page_DeadLetter", schemaName(reflect.TypeOf(Page[DeadLetter]{})))
+}
+
+func TestOpenAPIComponents(t *testing.T) {
+	endpoints := map[string]*Endpoint{
+		"/products":      {Path: "/products", Methods: []Method{{HTTPMethod: "POST", Request: new(schemaProduct), Response: new(schemaProduct)}}},
+		"/products/{id}": {Path: "/products/{id}", Methods: []Method{{HTTPMethod: "GET", Request: new(schemaProduct), Response: new(schemaProduct)}}},
+	}
+	spec, err := NewOpenAPI("test", "1.0", "").SetEndpoints(endpoints).Build()
+	assert.Nil(t, err)
+
+	schemas := spec["components"].(Map)["schemas"].(Map)
+	assert.Contains(t, schemas, "schemaProduct")
+	assert.Contains(t, schemas, "schemaProduct_Input")
+
+	post := spec["paths"].(Map)["/products"].(Map)["post"].(Map)
+	body := post["requestBody"].(Map)["content"].(Map)[MediaTypeJSON].(Map)["schema"]
+	assert.Equal(t, Map{"$ref": "#/components/schemas/schemaProduct_Input"}, body)
+}
+
+func TestOpenAPIErrorResponses(t *testing.T) {
+	conflict := ErrorResponse{
+		StatusCode:  http.StatusConflict,
+		Description: "The product already exists",
+		Example:     Error{Code: "DUPLICATED_ERROR", Reason: "Conflict", Message: "Product already exists"},
+	}
+	endpoints := map[string]*Endpoint{
+		"/products": {Path: "/products", Methods: []Method{{
+			HTTPMethod: "POST",
+			Request:    new(schemaProduct),
+			Response:   new(schemaProduct),
+			Configs:    *getConfigs(WithErrorResponses(conflict)),
+		}}},
+		"/products/{id}": {Path: "/products/{id}", Methods: []Method{{
+			HTTPMethod: "PATCH",
+			Request:    new(schemaProduct),
+			Response:   new(schemaProduct),
+			Configs: EndpointConfigs{
+				PathParams: []AllowedFields{{Name: "id", Required: true}},
+				Consumes:   []string{MediaTypeJSON, MediaTypeMergePatch},
+			},
+		}}},
+	}
+	spec, err := NewOpenAPI("test", "1.0", "").SetEndpoints(endpoints).Build()
+	assert.Nil(t, err)
+	responses := func(path, method string) Map {
+		return spec["paths"].(Map)[path].(Map)[method].(Map)["responses"].(Map)
+	}
+
+	t.Run("should document the default errors", func(t *testing.T) {
+		post := responses("/products", "post")
+		for _, status := range []string{"400", "405", "422", "500"} {
+			assert.Contains(t, post, status)
+		}
+		assert.NotContains(t, post, "404")
+		assert.NotContains(t, post, "415")
+
+		patch := responses("/products/{id}", "patch")
+		assert.Contains(t, patch, "404")
+		assert.Contains(t, patch, "415")
+		assert.NotContains(t, patch, "422")
+	})
+
+	t.Run("should reference the error schema", func(t *testing.T) {
+		content := responses("/products", "post")["400"].(Map)["content"].(Map)["application/json"].(Map)
+		assert.Equal(t, Map{"$ref": "#/components/schemas/Error"}, content["schema"])
+		assert.Contains(t, content["examples"], "REQUIRED_FIELD_ERR")
+		assert.Contains(t, spec["components"].(Map)["schemas"], "Error")
+	})
+
+	t.Run("should document custom errors", func(t *testing.T) {
+		response := responses("/products", "post")["409"].(Map)
+		assert.Equal(t, "The product already exists", response["description"])
+		examples := response["content"].(Map)["application/json"].(Map)["examples"].(Map)
+		assert.Equal(t, Map{"summary": "Product already exists", "value": conflict.Example}, examples["DUPLICATED_ERROR"])
+	})
+}
+
+func TestOpenAPISuccessResponses(t *testing.T) {
+	engine := newTestEngine()
+	endpoint := New[testItem, testItem]("/statuses", engine.Group("/v1"))
+	endpoint.HandleCreate("", func(item testItem, params *RequestParams) (*Err, *testItem) { return nil, &item })
+	endpoint.HandleCreateWithoutBody("/:id/copy", func(params *RequestParams) (*Err, *testItem) { return nil, nil }, WithStatusCode(http.StatusAccepted))
+	endpoint.HandleList("", func(params *RequestParams, limit int, offset int) ([]*testItem, *Err, int, int) {
+		return nil, nil, 0, 0
+	})
+	endpoint.HandleUpsert("/:id", func(item testItem, params *RequestParams) (*Err, *testItem, bool) { return nil, &item, false })
+	endpoint.HandleDelete("/:id", func(params *RequestParams) *Err { return nil })
+
+	spec, err := NewOpenAPI("test", "1.0", "").SetEndpoints(map[string]*Endpoint{
+		"/v1/statuses":           Endpoints["/v1/statuses"],
+		"/v1/statuses/{id}":      Endpoints["/v1/statuses/{id}"],
+		"/v1/statuses/{id}/copy": Endpoints["/v1/statuses/{id}/copy"],
+	}).Build()
+	assert.Nil(t, err)
+	responses := func(path, method string) Map {
+		return spec["paths"].(Map)[path].(Map)[method].(Map)["responses"].(Map)
+	}
+	item := Map{"$ref": "#/components/schemas/testItem"}
+
+	t.Run("should document the handler status codes", func(t *testing.T) {
+		assert.Contains(t, responses("/v1/statuses", "post"), "201")
+		assert.NotContains(t, responses("/v1/statuses", "post"), "200")
+		assert.Contains(t, responses("/v1/statuses/{id}/copy", "post"), "202")
+		assert.Contains(t, responses("/v1/statuses/{id}", "put"), "200")
+		assert.Contains(t, responses("/v1/statuses/{id}", "put"), "201")
+	})
+
+	t.Run("should document lists as arrays with paging headers", func(t *testing.T) {
+		for _, status := range []string{"200", "206"} {
+			response := responses("/v1/statuses", "get")[status].(Map)
+			schema := response["content"].(Map)["application/json"].(Map)["schema"]
+			assert.Equal(t, Map{"type": "array", "items": item}, schema)
+			assert.Contains(t, response["headers"], "X-Total-Count")
+			assert.Contains(t, response["headers"], "X-Result-Count")
+		}
+	})
+
+	t.Run("should document 204 without a body", func(t *testing.T) {
+		assert.Equal(t, Map{"description": "No Content"}, responses("/v1/statuses/{id}", "delete")["204"])
+	})
+}
+
+type nullableItem struct {
+	Name     *string         `json:"name" nullable:"true" example:"pen"`
+	Category *schemaCategory `json:"category" nullable:"true"`
+}
+
+func TestOpenAPIVersions(t *testing.T) {
+	endpoints := map[string]*Endpoint{
+		"/items": {Path: "/items", Methods: []Method{{HTTPMethod: "GET", Response: new(nullableItem)}}},
+	}
+	properties := func(spec Map) Map {
+		return spec["components"].(Map)["schemas"].(Map)["nullableItem"].(Map)["properties"].(Map)
+	}
+
+	t.Run("should default to 3.0", func(t *testing.T) {
+		spec, err := NewOpenAPI("test", "1.0", "").SetEndpoints(endpoints).Build()
+		assert.Nil(t, err)
+		assert.Equal(t, "3.0.3", spec["openapi"])
+		assert.Equal(t, Map{"type": "string", "nullable": true, "example": "pen"}, properties(spec)["name"])
+		assert.Equal(t, Map{"nullable": true, "allOf": []Map{{"$ref": "#/components/schemas/schemaCategory"}}}, properties(spec)["category"])
+	})
+
+	t.Run("should use JSON Schema 2020-12 semantics for 3.1", func(t *testing.T) {
+		spec, err := NewOpenAPI("test", "1.0", "").SetVersion(OpenAPI31).SetEndpoints(endpoints).Build()
+		assert.Nil(t, err)
+		assert.Equal(t, "3.1.0", spec["openapi"])
+		assert.Equal(t, Map{"type": []string{"string", "null"}, "examples": []string{"pen"}}, properties(spec)["name"])
+		assert.Equal(t, Map{"anyOf": []Map{{"$ref": "#/components/schemas/schemaCategory"}, {"type": "null"}}}, properties(spec)["category"])
+	})
+
+	t.Run("should encode to YAML", func(t *testing.T) {
+		spec, _ := NewOpenAPI("test", "1.0", "").SetEndpoints(endpoints).Build()
+		data, err := spec.YAML()
+		assert.Nil(t, err)
+		assert.Contains(t, string(data), "openapi: 3.0.3\n")
+		assert.Contains(t, string(data), "$ref: '#/components/schemas/nullableItem'")
+	})
+}
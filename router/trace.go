@@ -0,0 +1,49 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"regexp"
+	"strings"
+)
+
+const (
+	// RequestIDHeader carries the trace ID of a request and its response.
+	RequestIDHeader = "X-Request-ID"
+	// TraceparentHeader is the W3C Trace Context header, whose trace-id is
+	// used when the request has no RequestIDHeader.
+	TraceparentHeader = "traceparent"
+)
+
+// requestIDPattern restricts accepted request IDs to short tokens safe to log
+// and echo back.
+var requestIDPattern = regexp.MustCompile(`^[\w.:/+=-]{1,128}$`)
+
+// traceparentPattern matches a W3C traceparent: version, trace-id, parent-id
+// and flags.
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// Trace returns a middleware that identifies every request with a trace ID,
+// taken from the X-Request-ID header, the trace-id of the traceparent header
+// or generated. The ID is stored in the request context, where
+// RequestParams.TraceID is read from, and echoed in the X-Request-ID response
+// header.
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := requestTraceID(c)
+		c.Request = c.Request.WithContext(ContextWithTraceID(c.Request.Context(), traceID))
+		c.Header(RequestIDHeader, traceID)
+		c.Next()
+	}
+}
+
+func requestTraceID(c *gin.Context) string {
+	if id := c.GetHeader(RequestIDHeader); requestIDPattern.MatchString(id) {
+		return id
+	}
+	if match := traceparentPattern.FindStringSubmatch(strings.ToLower(c.GetHeader(TraceparentHeader))); match != nil {
+		if strings.Trim(match[1], "0") != "" {
+			return match[1]
+		}
+	}
+	return newID()
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	engine := newTestEngine()
+	engine.Use(Trace())
+	endpoint := New[testItem, testItem]("/traced", engine.Group("/v1"))
+	endpoint.HandleRead("/:id", func(params *RequestParams) (*Err, *testItem) {
+		return nil, &testItem{Name: &params.TraceID}
+	})
+
+	request := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/traced/1", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should accept the request ID", func(t *testing.T) {
+		w := request(map[string]string{RequestIDHeader: "req-42", TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+		assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+		assert.JSONEq(t, `{"name":"req-42"}`, w.Body.String())
+	})
+
+	t.Run("should accept the traceparent trace ID", func(t *testing.T) {
+		w := request(map[string]string{TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(RequestIDHeader))
+		assert.JSONEq(t, `{"name":"4bf92f3577b34da6a3ce929d0e0e4736"}`, w.Body.String())
+	})
+
+	t.Run("should generate missing or invalid IDs", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			nil,
+			{RequestIDHeader: "has spaces\nand newlines"},
+			{TraceparentHeader: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+			{TraceparentHeader: "garbage"},
+		} {
+			w := request(headers)
+			assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+		}
+		assert.NotEqual(t, request(nil).Header().Get(RequestIDHeader), request(nil).Header().Get(RequestIDHeader))
+	})
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/grahms/godantic"
 	"net/http"
+	"strings"
 )
 
 type Validation struct {
@@ -20,6 +21,15 @@ func (va *Validation) contentType() (int, Error) {
 
 }
 
+func (va *Validation) unsupportedMediaType(accepted []string) (int, Error) {
+	exp := Error{
+		Code:    "UNSUPPORTED_MEDIA_TYPE_ERR",
+		Reason:  "Unsupported Media Type",
+		Message: "The request content type is not supported, content type should be one of `" + strings.Join(accepted, "`, `") + "`",
+	}
+	return http.StatusUnsupportedMediaType, exp
+}
+
 func (va *Validation) ProcessorErr(perr *Err) (int, Error) {
 	exp := Error{
 		Code:    perr.ErrCode,
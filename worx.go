@@ -8,7 +8,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/grahms/worx/router"
 	"html/template"
+	"log/slog"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,21 +22,46 @@ type Application struct {
 	Engine      *gin.Engine
 	version     string
 	description string
+	openAPI     router.OpenAPIVersion
+	timeouts    ServerTimeouts
+	lifecycle   lifecycle
+
+	// mu guards the health checks, the panic hooks and the security schemes.
+	mu              sync.Mutex
+	healthChecks    []*healthCheck
+	panicHooks      []router.PanicHook
+	securitySchemes map[string]*router.SecurityScheme
+
+	metrics atomic.Pointer[router.Metrics]
+	logger  atomic.Value
 }
 
-func NewRouter[In, Out any](app *Application, path string) *router.APIEndpoint[In, Out] {
-
-	return router.New[In, Out](path, app.router.Group(""))
+// NewRouter returns an endpoint under the application path. middlewares run
+// before every handler of the endpoint.
+func NewRouter[In, Out any](app *Application, path string, middlewares ...gin.HandlerFunc) *router.APIEndpoint[In, Out] {
+	return router.New[In, Out](path, app.router.Group("")).Use(middlewares...).UseSecuritySchemes(app.securitySchemes)
 }
 
 func NewApplication(path, name, version, description string, middlewares ...gin.HandlerFunc) *Application {
-	r := Engine()
+	r := gin.New()
+	app := &Application{
+		name:        name,
+		path:        path,
+		Engine:      r,
+		version:     version,
+		description: description,
+		timeouts:    DefaultServerTimeouts,
+
+		securitySchemes: make(map[string]*router.SecurityScheme),
+	}
+	app.logger.Store(Logger())
+	r.Use(router.Trace(), app.log, router.Recovery(app.panicked))
 
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 
-	config.ExposeHeaders = []string{"Content-Length", "X-Result-Count", "X-Total-Count", "Content-Type"}
-	r.Use(cors.New(config))
+	config.ExposeHeaders = []string{"Content-Length", "X-Result-Count", "X-Total-Count", "Content-Type", router.RequestIDHeader}
+	r.Use(cors.New(config), app.measure)
 	// Optionally apply custom middleware
 	for _, mw := range middlewares {
 		r.Use(mw)
@@ -41,31 +69,122 @@ func NewApplication(path, name, version, description string, middlewares ...gin.
 	r.HandleMethodNotAllowed = true
 	noMethod(r)
 	noRoute(r)
-	g := r.Group(path)
-	app := &Application{
-		name:        name,
-		path:        path,
-		router:      g,
-		Engine:      r,
-		version:     version,
-		description: description,
-	}
+	app.router = r.Group(path)
+	app.renderHealth()
 	return app
 }
 
 type _ any
 
-func (a *Application) Run(address string) error {
-	a.renderDocs()
-	return a.Engine.Run(address)
+// AddDispatcher runs d for as long as the application runs and exposes its dead
+// letter queue under /admin/deadLetters. The queue holds the events and
+// callbacks of every listener, so opts must protect it with router.WithSecurity.
+func (a *Application) AddDispatcher(d *router.Dispatcher, opts ...router.HandleOption) {
+	var config router.EndpointConfigs
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if len(config.Security) == 0 {
+		panic("worx: AddDispatcher requires router.WithSecurity to protect the dead letter endpoints")
+	}
+	a.OnStart(d.Start)
+	a.OnStop(d.Stop)
+	d.HandleDeadLetters(NewRouter[router.DeadLetter, router.DeadLetter](a, "/admin/deadLetters"), opts...)
+}
+
+// SetOpenAPIVersion selects the OpenAPI version of the served documents,
+// router.OpenAPI30 by default.
+func (a *Application) SetOpenAPIVersion(version router.OpenAPIVersion) {
+	a.openAPI = version
+}
+
+// Spec builds the OpenAPI document of the registered endpoints.
+func (a *Application) Spec() (router.Map, error) {
+	spec := router.NewOpenAPI(a.name, a.version, a.description)
+	if a.openAPI != "" {
+		spec.SetVersion(a.openAPI)
+	}
+	schemes := make(map[string]*router.SecurityScheme)
+	for name, scheme := range router.SecuritySchemes {
+		schemes[name] = scheme
+	}
+	a.mu.Lock()
+	for name, scheme := range a.securitySchemes {
+		schemes[name] = scheme
+	}
+	a.mu.Unlock()
+	return spec.SetSecuritySchemes(schemes).SetEndpoints(router.Endpoints).Build()
+}
+
+// AddSecurityScheme declares a security scheme the endpoints of the
+// application can require with router.WithSecurity. Schemes must be declared
+// before the handlers requiring them are registered.
+func (a *Application) AddSecurityScheme(name string, scheme *router.SecurityScheme) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.securitySchemes[name] = scheme
+}
+
+// EnableMetrics records request metrics and serves them on /metrics in the
+// Prometheus text exposition format. buckets are the latency histogram buckets
+// in seconds, router.DefaultBuckets when omitted.
+func (a *Application) EnableMetrics(buckets ...float64) *router.Metrics {
+	metrics := router.NewMetrics(buckets...)
+	if !a.metrics.CompareAndSwap(nil, metrics) {
+		return a.metrics.Load()
+	}
+	a.Engine.GET("/metrics", metrics.Handler())
+	return metrics
+}
+
+// measure feeds the metrics once they are enabled. It is installed with the
+// application so routes registered before EnableMetrics are measured too.
+func (a *Application) measure(c *gin.Context) {
+	if metrics := a.metrics.Load(); metrics != nil {
+		metrics.Middleware()(c)
+	}
+}
+
+// OnPanic calls hook with every panic recovered while serving requests, ex: to
+// report it to an error tracker.
+func (a *Application) OnPanic(hook router.PanicHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.panicHooks = append(a.panicHooks, hook)
+}
+
+func (a *Application) panicked(c *gin.Context, recovered any, stack []byte) {
+	a.mu.Lock()
+	hooks := a.panicHooks
+	a.mu.Unlock()
+	for _, hook := range hooks {
+		hook(c, recovered, stack)
+	}
+}
+
+// SetLogger logs requests to logger, as structured entries, instead of the
+// default JSON lines written to gin.DefaultWriter. Handlers log through the
+// request scoped logger found in router.RequestParams.Logger.
+func (a *Application) SetLogger(logger *slog.Logger, opts ...router.LogOption) {
+	a.logger.Store(router.RequestLogger(logger, opts...))
+}
+
+// log runs the current request logger. It is installed with the application
+// so SetLogger applies to routes registered before it.
+func (a *Application) log(c *gin.Context) {
+	a.logger.Load().(gin.HandlerFunc)(c)
 }
 
 func (a *Application) renderDocs() {
-	s, err := router.NewOpenAPI(a.name, a.version, a.description).SetEndpoints(router.Endpoints).Build()
+	s, err := a.Spec()
 	if err != nil {
 		panic(err)
 	}
 	bJ, _ := json.Marshal(s)
+	bY, err := s.YAML()
+	if err != nil {
+		panic(err)
+	}
 
 	a.Engine.GET("/spec", RenderSwagg(string(bJ))) // Serve swagger ui
 	a.Engine.GET("/openapi.json", func(c *gin.Context) {
@@ -73,6 +192,9 @@ func (a *Application) renderDocs() {
 		c.Header("Content-Type", "application/json")
 		c.String(200, string(bJ))
 	})
+	a.Engine.GET("/openapi.yaml", func(c *gin.Context) {
+		c.Data(200, "application/yaml", bY)
+	})
 	a.Engine.GET("/redoc", func(c *gin.Context) {
 
 		c.Data(200, "text/html; charset=utf-8", []byte(redocHTML))
@@ -99,7 +221,7 @@ func noMethod(r *gin.Engine) {
 func Engine() *gin.Engine {
 
 	engine := gin.New()
-	engine.Use(Logger(), gin.Recovery())
+	engine.Use(router.Trace(), Logger(), router.Recovery())
 	return engine
 }
 
@@ -183,6 +305,11 @@ var defaultLogFormatter = func(param gin.LogFormatterParams) string {
 		"path":      param.Path,
 		"error":     param.ErrorMessage,
 	}
+	if param.Request != nil {
+		if traceID, ok := router.TraceIDFromContext(param.Request.Context()); ok {
+			logData["trace_id"] = traceID
+		}
+	}
 
 	logJSON, err := json.Marshal(logData)
 	if err != nil {
@@ -0,0 +1,191 @@
+package worx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/grahms/worx/router"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type specItem struct {
+	Name *string `json:"name" binding:"required"`
+}
+
+func newTestApplication() *Application {
+	gin.SetMode(gin.TestMode)
+	return NewApplication("/api", "Test API", "1.0.0", "Test API")
+}
+
+func TestApplicationSpec(t *testing.T) {
+	app := newTestApplication()
+	app.SetOpenAPIVersion(router.OpenAPI31)
+	NewRouter[specItem, specItem](app, "/specItems").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+		return nil, &specItem{}
+	})
+
+	spec, err := app.Spec()
+	assert.Nil(t, err)
+	assert.Equal(t, "3.1.0", spec["openapi"])
+	assert.Contains(t, spec["paths"], "/api/specItems/{id}")
+
+	app.renderDocs()
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "openapi: 3.1.0\n")
+}
+
+func TestApplicationSecuritySchemes(t *testing.T) {
+	app := newTestApplication()
+	app.AddSecurityScheme("appKey", router.APIKeyScheme("X-App-Key", func(_ context.Context, credential string) (*router.Principal, error) {
+		if credential != "k1" {
+			return nil, errors.New("unknown credential")
+		}
+		return &router.Principal{Subject: "service-a"}, nil
+	}))
+	NewRouter[specItem, specItem](app, "/securedItems").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+		return nil, &specItem{}
+	}, router.WithSecurity("appKey"))
+	assert.NotContains(t, router.SecuritySchemes, "appKey")
+
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/securedItems/1", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/securedItems/1", nil)
+	req.Header.Set("X-App-Key", "k1")
+	w = httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	spec, err := app.Spec()
+	assert.Nil(t, err)
+	assert.Contains(t, spec["components"].(router.Map)["securitySchemes"], "appKey")
+	assert.NotContains(t, newTestApplication().securitySchemes, "appKey")
+}
+
+func TestApplicationAddDispatcher(t *testing.T) {
+	app := newTestApplication()
+	d := router.NewDispatcher(router.NewHub(nil))
+	assert.Panics(t, func() {
+		app.AddDispatcher(d)
+	})
+
+	app.AddSecurityScheme("adminKey", router.APIKeyScheme("X-Admin-Key", func(_ context.Context, credential string) (*router.Principal, error) {
+		if credential != "admin" {
+			return nil, errors.New("unknown credential")
+		}
+		return &router.Principal{Subject: "admin"}, nil
+	}))
+	app.AddDispatcher(d, router.WithSecurity("adminKey"))
+
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/deadLetters", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/deadLetters", nil)
+	req.Header.Set("X-Admin-Key", "admin")
+	w = httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestApplicationMetrics(t *testing.T) {
+	app := newTestApplication()
+	NewRouter[specItem, specItem](app, "/measuredItems").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+		return nil, &specItem{}
+	})
+	app.Engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/measuredItems/1", nil))
+
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Same(t, app.EnableMetrics(), app.EnableMetrics())
+	app.Engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/measuredItems/1", nil))
+
+	w = httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `worx_http_requests_total{method="GET",route="/api/measuredItems/{id}",status="200"} 1`)
+}
+
+func TestApplicationTrace(t *testing.T) {
+	app := newTestApplication()
+
+	for _, target := range []string{"/missing", "/health/live"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(router.RequestIDHeader, "req-1")
+		w := httptest.NewRecorder()
+		app.Engine.ServeHTTP(w, req)
+		assert.Equal(t, "req-1", w.Header().Get(router.RequestIDHeader))
+	}
+
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/health/live", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.NotEmpty(t, w.Header().Get(router.RequestIDHeader))
+}
+
+func TestLoggerTraceID(t *testing.T) {
+	var out bytes.Buffer
+	engine := gin.New()
+	engine.Use(router.Trace(), LoggerWithConfig(gin.LoggerConfig{Output: &out}))
+	engine.GET("/logged", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/logged", nil)
+	req.Header.Set(router.RequestIDHeader, "req-2")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "req-2", entry["trace_id"])
+	assert.Equal(t, float64(http.StatusNoContent), entry["status"])
+}
+
+func TestApplicationSetLogger(t *testing.T) {
+	app := newTestApplication()
+	NewRouter[specItem, specItem](app, "/loggedItems").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+		return nil, &specItem{}
+	})
+
+	var out bytes.Buffer
+	app.SetLogger(slog.New(slog.NewJSONHandler(&out, nil)))
+	req := httptest.NewRequest(http.MethodGet, "/api/loggedItems/1", nil)
+	req.Header.Set(router.RequestIDHeader, "req-3")
+	app.Engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "req-3", entry["trace_id"])
+	assert.Equal(t, "/api/loggedItems/{id}", entry["route"])
+}
+
+func TestApplicationOnPanic(t *testing.T) {
+	app := newTestApplication()
+	app.SetLogger(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	var reported any
+	app.OnPanic(func(c *gin.Context, recovered any, stack []byte) {
+		reported = recovered
+	})
+	NewRouter[specItem, specItem](app, "/panickingItems").HandleRead("/:id", func(params *router.RequestParams) (*router.Err, *specItem) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	app.Engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/panickingItems/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "INTERNAL_SERVER_ERROR")
+	assert.Equal(t, "boom", reported)
+}